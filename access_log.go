@@ -0,0 +1,164 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"time"
+
+	contractslog "github.com/goravel/framework/contracts/log"
+	"google.golang.org/grpc/codes"
+)
+
+const redacted = "[REDACTED]"
+
+// accessLogOptions is the gateway.access_log configuration, it's nil if the access log is disabled.
+type accessLogOptions struct {
+	channel    string
+	sampleRate float64
+	redact     map[string]bool
+}
+
+// accessLog writes an entry for each request of the controller and the ServeMux via the log facade. The entries of
+// failed requests (5xx) are always written, the others are sampled by gateway.access_log.sample_rate.
+type accessLog struct {
+	log     contractslog.Log
+	options accessLogOptions
+}
+
+// newAccessLog returns nil if the access log is disabled or the log facade isn't available, a nil *accessLog doesn't
+// write anything.
+func newAccessLog(options *accessLogOptions) *accessLog {
+	if options == nil || App == nil {
+		return nil
+	}
+
+	log := App.MakeLog()
+	if log == nil {
+		return nil
+	}
+	if options.channel != "" {
+		log = log.Channel(options.channel)
+	}
+
+	return &accessLog{log: log, options: *options}
+}
+
+// accessLogEntry is a request written to the access log, source is controller or gateway.
+type accessLogEntry struct {
+	source     string
+	method     string
+	path       string
+	grpcMethod string
+	status     int
+	grpcCode   codes.Code
+	start      time.Time
+	bytes      int
+	requestID  string
+	// injected are the keys injected by the controller, their values are never written.
+	injected []string
+	err      error
+}
+
+// fail records the error passed to the fallback, the status is the one that HTTPStatusFromError returns.
+func (r *accessLogEntry) fail(err error) {
+	r.err = err
+	r.status = HTTPStatusFromError(err)
+
+	var (
+		statusErr    *StatusError
+		rateLimitErr *RateLimitError
+	)
+	if errors.As(err, &statusErr) {
+		r.grpcCode = statusErr.Code
+	} else if errors.As(err, &rateLimitErr) {
+		r.grpcCode = codes.ResourceExhausted
+	} else {
+		r.grpcCode = codes.Unknown
+	}
+}
+
+func (r *accessLogEntry) fields() map[string]any {
+	fields := map[string]any{
+		"source":      r.source,
+		"method":      r.method,
+		"path":        r.path,
+		"grpc_method": r.grpcMethod,
+		"status":      r.status,
+		"grpc_code":   r.grpcCode.String(),
+		"latency_ms":  float64(time.Since(r.start).Microseconds()) / 1000,
+		"bytes":       r.bytes,
+		"request_id":  r.requestID,
+	}
+
+	if len(r.injected) > 0 {
+		injected := make(map[string]any, len(r.injected))
+		for _, key := range r.injected {
+			injected[key] = redacted
+		}
+		fields["injected"] = injected
+	}
+	if r.err != nil {
+		fields["error"] = r.err.Error()
+	}
+
+	return fields
+}
+
+func (r *accessLog) write(entry *accessLogEntry) {
+	if r == nil {
+		return
+	}
+	if entry.status < http.StatusInternalServerError && rand.Float64() >= r.options.sampleRate {
+		return
+	}
+
+	fields := entry.fields()
+	for key := range r.options.redact {
+		if _, exist := fields[key]; exist {
+			fields[key] = redacted
+		}
+	}
+
+	writer := r.log.With(fields)
+	message := fmt.Sprintf("[Gateway] %s %s %d", entry.method, entry.path, entry.status)
+	if entry.status >= http.StatusInternalServerError {
+		writer.Error(message)
+	} else {
+		writer.Info(message)
+	}
+}
+
+// observe writes the entry of a request served by the ServeMux, it's registered with registerRequestInfo.
+func (r *accessLog) observe(info *requestInfo) {
+	code, message := info.grpcStatus()
+	entry := &accessLogEntry{
+		source:     "gateway",
+		method:     info.httpMethod,
+		path:       info.path,
+		grpcMethod: info.grpcMethod(),
+		status:     info.writer.status,
+		grpcCode:   code,
+		start:      info.start,
+		bytes:      info.writer.bytes,
+		requestID:  info.requestID,
+	}
+	if message != "" {
+		entry.err = errors.New(message)
+	}
+
+	r.write(entry)
+}
+
+// injectedKeys returns the sorted keys of the injected values.
+func injectedKeys(injected map[string]any) []string {
+	keys := make([]string, 0, len(injected))
+	for key := range injected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
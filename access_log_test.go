@@ -0,0 +1,180 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	configmocks "github.com/goravel/framework/mocks/config"
+	foundationmocks "github.com/goravel/framework/mocks/foundation"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	logmocks "github.com/goravel/framework/mocks/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/goravel/gateway/proto/example"
+)
+
+func TestAccessLog(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:4011")
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	example.RegisterUserServiceServer(server, NewUserController())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	mockApp := new(foundationmocks.Application)
+	mockLog := new(logmocks.Log)
+	mockWriter := new(logmocks.Writer)
+	App = mockApp
+	defer func() {
+		App = nil
+	}()
+
+	var entries []map[string]any
+	mockApp.On("MakeLog").Return(mockLog).Once()
+	mockLog.On("Channel", "gateway").Return(mockLog).Once()
+	mockLog.On("With", mock.Anything).Run(func(args mock.Arguments) {
+		fields := args.Get(0).(map[string]any)
+		assert.Contains(t, fields, "latency_ms")
+		delete(fields, "latency_ms")
+		entries = append(entries, fields)
+	}).Return(mockWriter)
+	mockWriter.On("Info", "[Gateway] GET /users/1 200").Once()
+	mockWriter.On("Info", "[Gateway] GET /users/0 404").Once()
+
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return nil
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(true)
+	mockConfig.On("GetString", "gateway.access_log.channel").Return("gateway")
+	mockConfig.On("Get", "gateway.access_log.sample_rate").Return(nil)
+	mockConfig.On("Get", "gateway.access_log.redact").Return([]string{"request_id"})
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(false)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "example").Return(newTestConnection(t, "127.0.0.1:4011"), nil)
+
+	require.Nil(t, gateway.Run())
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	for _, path := range []string{"/users/1", "/users/0"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Request-Id", "1")
+		gateway.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, entries, 2)
+	assert.Greater(t, entries[0]["bytes"], 0)
+	delete(entries[0], "bytes")
+	assert.Equal(t, map[string]any{
+		"source":      "gateway",
+		"method":      http.MethodGet,
+		"path":        "/users/1",
+		"grpc_method": "example.UserService/GetUser",
+		"status":      http.StatusOK,
+		"grpc_code":   "OK",
+		"request_id":  redacted,
+	}, entries[0])
+	assert.Greater(t, entries[1]["bytes"], 0)
+	delete(entries[1], "bytes")
+	assert.Equal(t, map[string]any{
+		"source":      "gateway",
+		"method":      http.MethodGet,
+		"path":        "/users/0",
+		"grpc_method": "example.UserService/GetUser",
+		"status":      http.StatusNotFound,
+		"grpc_code":   "NotFound",
+		"request_id":  redacted,
+		"error":       "user not found",
+	}, entries[1])
+
+	mockApp.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+	mockWriter.AssertExpectations(t)
+	mockConfig.AssertExpectations(t)
+	mockGrpc.AssertExpectations(t)
+}
+
+func TestAccessLogWrite(t *testing.T) {
+	mockLog := new(logmocks.Log)
+	mockWriter := new(logmocks.Writer)
+	log := &accessLog{
+		log: mockLog,
+		options: accessLogOptions{
+			// Only the failed requests are written.
+			sampleRate: 0,
+			redact:     map[string]bool{},
+		},
+	}
+
+	succeeded := &accessLogEntry{
+		source:   "controller",
+		method:   http.MethodGet,
+		path:     "/users/1",
+		status:   http.StatusOK,
+		grpcCode: codes.OK,
+		start:    time.Now(),
+	}
+	log.write(succeeded)
+
+	failed := &accessLogEntry{
+		source:   "controller",
+		method:   http.MethodPost,
+		path:     "/users",
+		grpcCode: codes.OK,
+		start:    time.Now(),
+		injected: []string{"user_id"},
+	}
+	failed.fail(errors.New("connection refused"))
+
+	mockLog.On("With", mock.MatchedBy(func(fields map[string]any) bool {
+		return fields["status"] == http.StatusInternalServerError &&
+			fields["grpc_code"] == "Unknown" &&
+			fields["error"] == "connection refused" &&
+			assert.ObjectsAreEqual(map[string]any{"user_id": redacted}, fields["injected"])
+	})).Return(mockWriter).Once()
+	mockWriter.On("Error", "[Gateway] POST /users 500").Once()
+	log.write(failed)
+
+	// A nil access log is disabled.
+	var disabled *accessLog
+	disabled.write(failed)
+
+	mockLog.AssertExpectations(t)
+	mockWriter.AssertExpectations(t)
+}
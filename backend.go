@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gookit/color"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// StartupDegraded starts the Gateway even if some backends are unreachable, the routes of them answer 503 until
+	// they are available.
+	StartupDegraded = "degraded"
+	// StartupFailFast makes Run return an error if a backend isn't ready in gateway.connection.timeout.
+	StartupFailFast = "fail_fast"
+)
+
+// backend is a gRPC client of the Gateway. The connection is dialed lazily by the first request, and its connectivity
+// state is watched so that the routes of an unavailable backend answer 503 without waiting for the RPC to fail.
+type backend struct {
+	name      string
+	conn      *grpc.ClientConn
+	available atomic.Bool
+	// breaker is nil if gateway.circuit_breaker is disabled.
+	breaker *circuitBreaker
+}
+
+func newBackend(name string, conn *grpc.ClientConn) *backend {
+	backend := &backend{
+		name: name,
+		conn: conn,
+	}
+	// The state is unknown before the connection is dialed, the first request decides it.
+	backend.available.Store(true)
+
+	return backend
+}
+
+// middleware answers 503 for the routes of the backend if it's unavailable, the connection keeps reconnecting in the
+// background.
+func (r *backend) middleware(mux *runtime.ServeMux, next runtime.HandlerFunc) runtime.HandlerFunc {
+	if r.breaker != nil {
+		next = r.breaker.middleware(mux, next)
+	}
+
+	return func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		if !r.available.Load() {
+			r.conn.Connect()
+
+			_, outbound := runtime.MarshalerForRequest(mux, req)
+			runtime.HTTPError(req.Context(), mux, outbound, w, req, status.Errorf(codes.Unavailable, "gRPC %s backend is unavailable", r.name))
+			return
+		}
+
+		next(w, req, params)
+	}
+}
+
+// waitForReady dials the connection and waits until it's ready or ctx is done.
+func (r *backend) waitForReady(ctx context.Context) error {
+	r.conn.Connect()
+
+	for {
+		state := r.conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+
+		if !r.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("gRPC %s backend isn't ready, the connection state is %s", r.name, state)
+		}
+	}
+}
+
+// watch updates the availability of the backend with the connectivity state until ctx is done.
+func (r *backend) watch(ctx context.Context) {
+	state := r.conn.GetState()
+	for {
+		r.update(state)
+
+		if !r.conn.WaitForStateChange(ctx, state) {
+			return
+		}
+
+		state = r.conn.GetState()
+	}
+}
+
+// update keeps the availability when the connection is idle or connecting, it only changes if the connection is
+// established or fails.
+func (r *backend) update(state connectivity.State) {
+	switch state {
+	case connectivity.Ready:
+		if !r.available.Swap(true) {
+			color.Greenln(fmt.Sprintf("[Gateway] gRPC %s backend is available", r.name))
+		}
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		if r.available.Swap(false) {
+			color.Redln(fmt.Sprintf("[Gateway] gRPC %s backend is unavailable, the connection state is %s", r.name, state))
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/foundation/json"
+)
+
+const (
+	MIMEJSON          = "application/json"
+	MIMEForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartForm = "multipart/form-data"
+	MIMEProtobuf      = "application/x-protobuf"
+	MIMEEventStream   = "text/event-stream"
+
+	maxMultipartMemory = 32 << 20
+)
+
+// newBody builds the body that is sent to the Gateway and returns its Content-Type. Form fields are converted to JSON,
+// protobuf binary is passed through untouched, queries and injected values are merged into JSON objects because the
+// Gateway only reads the body of these methods. merged is false if the injected values aren't in the body, they are
+// added to the query in that case.
+func newBody(ctx contractshttp.Context, injected map[string]any) (body io.Reader, contentType string, merged bool, err error) {
+	request := ctx.Request().Origin()
+	mediaType, _, err := mime.ParseMediaType(ctx.Request().Header("Content-Type", MIMEJSON))
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	var dataJson map[string]any
+	switch mediaType {
+	case MIMEProtobuf:
+		// The injected fields can't be merged into protobuf binary without the message descriptor.
+		if err := rejectInjected(ctx, injected, ErrInjectProtobuf); err != nil {
+			return nil, "", false, err
+		}
+
+		return request.Body, MIMEProtobuf, false, nil
+	case MIMEForm:
+		if err := request.ParseForm(); err != nil {
+			return nil, "", false, err
+		}
+
+		dataJson = formToJson(request.PostForm)
+	case MIMEMultipartForm:
+		if err := request.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return nil, "", false, err
+		}
+
+		dataJson = formToJson(request.MultipartForm.Value)
+		for key, files := range request.MultipartForm.File {
+			// The Gateway decodes bytes fields from base64.
+			contents := make([]string, 0, len(files))
+			for _, file := range files {
+				content, err := readFile(file)
+				if err != nil {
+					return nil, "", false, err
+				}
+
+				contents = append(contents, base64.StdEncoding.EncodeToString(content))
+			}
+			dataJson[key] = singleOrSlice(contents)
+		}
+	default:
+		data, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, "", false, err
+		}
+
+		data = bytes.TrimSpace(data)
+		switch {
+		case len(data) == 0:
+			dataJson = make(map[string]any)
+		case data[0] == '[':
+			// A JSON array can only be the body of a repeated field, the injected fields can't be merged into it.
+			if err := rejectInjected(ctx, injected, ErrInjectJSONArray); err != nil {
+				return nil, "", false, err
+			}
+
+			return bytes.NewReader(data), MIMEJSON, false, nil
+		default:
+			if err := json.New().Unmarshal(data, &dataJson); err != nil {
+				return nil, "", false, err
+			}
+			// A null body is unmarshalled into a nil map.
+			if dataJson == nil {
+				dataJson = make(map[string]any)
+			}
+		}
+	}
+
+	for key, value := range ctx.Request().Queries() {
+		dataJson[key] = value
+	}
+	injectBody(ctx, dataJson, injected)
+
+	data, err := json.New().Marshal(dataJson)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	return bytes.NewReader(data), MIMEJSON, true, nil
+}
+
+// rejectInjected returns err if a value is injected to a body that it can't be merged into, the request is rejected
+// instead of letting the client supply the injected fields. The overridable values are added to the query instead.
+func rejectInjected(ctx contractshttp.Context, injected map[string]any, err error) error {
+	overridable := injectOverridable(ctx, InjectKey)
+	for key := range injected {
+		if !overridable[key] {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formToJson(form url.Values) map[string]any {
+	dataJson := make(map[string]any, len(form))
+	for key, values := range form {
+		dataJson[key] = singleOrSlice(values)
+	}
+
+	return dataJson
+}
+
+func singleOrSlice(values []string) any {
+	if len(values) == 1 {
+		return values[0]
+	}
+
+	return values
+}
+
+func readFile(header *multipart.FileHeader) ([]byte, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open multipart file %s failed: %v", header.Filename, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return io.ReadAll(file)
+}
@@ -0,0 +1,341 @@
+package gateway
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	contractscache "github.com/goravel/framework/contracts/cache"
+	contractshttp "github.com/goravel/framework/contracts/http"
+
+	"github.com/goravel/gateway/contracts"
+)
+
+// CacheHeader tells the client whether the response of the controller is served from gateway.cache, it's HIT or MISS.
+const CacheHeader = "X-Gateway-Cache"
+
+type CacheStore = contracts.CacheStore
+
+// cacheOptions is the gateway.cache configuration, it's nil if the cache is disabled.
+type cacheOptions struct {
+	store   CacheStore
+	size    int
+	ttl     time.Duration
+	headers []string
+}
+
+// responseCache caches the successful responses of the GET requests of the controller. A response is cached for the
+// max-age of its Cache-Control, which the backend sets via the cache-control metadata, or for the default ttl if
+// there is no Cache-Control.
+type responseCache struct {
+	store   CacheStore
+	ttl     time.Duration
+	headers []string
+}
+
+// cachedResponse is the value kept in the store.
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	ETag   string      `json:"etag"`
+}
+
+// newResponseCache returns nil if the cache is disabled, a nil *responseCache doesn't cache anything. The responses
+// are kept in an in-memory LRU if gateway.cache.store isn't set.
+func newResponseCache(options *cacheOptions) *responseCache {
+	if options == nil {
+		return nil
+	}
+
+	store := options.store
+	if store == nil {
+		store = NewMemoryCacheStore(options.size)
+	}
+
+	headers := make([]string, 0, len(options.headers))
+	for _, header := range options.headers {
+		headers = append(headers, textproto.CanonicalMIMEHeaderKey(header))
+	}
+
+	return &responseCache{store: store, ttl: options.ttl, headers: headers}
+}
+
+// key identifies a response by the path, the normalized query, the headers of gateway.cache.headers, the injected
+// values and the injected metadata, so the responses of different users never share a key.
+func (r *responseCache) key(ctx contractshttp.Context, query url.Values, injected, injectedMetadata map[string]any) string {
+	return requestKey("gateway:cache:", ctx, query, r.headers, injected, injectedMetadata)
+}
+
+// get returns nil if the response isn't cached, the store errors are treated as misses. A request with Authorization
+// is only answered by a response that may be shared, see shareable.
+func (r *responseCache) get(ctx context.Context, key string, authorized bool) *cachedResponse {
+	data, exist, err := r.store.Get(ctx, key)
+	if err != nil || !exist {
+		return nil
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	if authorized && !shareable(cached.Header) {
+		return nil
+	}
+
+	return &cached
+}
+
+// put stores the response if its Cache-Control allows it, the store errors are ignored. The response of a request
+// with Authorization is only stored if it may be shared, see shareable.
+func (r *responseCache) put(ctx context.Context, key string, cached *cachedResponse, authorized bool) {
+	if authorized && !shareable(cached.Header) {
+		return
+	}
+
+	ttl, ok := cacheTTL(cached.Header, r.ttl)
+	if !ok {
+		return
+	}
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+
+	_ = r.store.Put(ctx, key, data, ttl)
+}
+
+// respond writes the response with its ETag, the body is omitted with 304 if the client already has it.
+func (r *responseCache) respond(ctx contractshttp.Context, entry *accessLogEntry, cached *cachedResponse, hit bool) contractshttp.Response {
+	header := ctx.Response().Writer().Header()
+	if hit {
+		for key, values := range cached.Header {
+			header[key] = values
+		}
+		header.Set(CacheHeader, "HIT")
+	} else {
+		header.Set(CacheHeader, "MISS")
+	}
+	header.Set("ETag", cached.ETag)
+
+	contentType := cached.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = MIMEJSON
+	}
+	if etagMatches(ctx.Request().Header("If-None-Match"), cached.ETag) {
+		entry.status = http.StatusNotModified
+		return ctx.Response().Data(http.StatusNotModified, contentType, nil)
+	}
+
+	entry.status = cached.Status
+	entry.bytes = len(cached.Body)
+
+	return ctx.Response().Data(cached.Status, contentType, cached.Body)
+}
+
+// requestKey hashes the path, the normalized query, the values of headers, the injected values and the injected
+// metadata of a GET request, the requests of the same key get the same response.
+func requestKey(prefix string, ctx contractshttp.Context, query url.Values, headers []string, injected, injectedMetadata map[string]any) string {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s\n%s\n", ctx.Request().Path(), query.Encode())
+	for _, header := range headers {
+		_, _ = fmt.Fprintf(hash, "%s: %q\n", header, ctx.Request().Headers().Values(header))
+	}
+	for _, key := range injectedKeys(injected) {
+		_, _ = fmt.Fprintf(hash, "%s=%v\n", key, injected[key])
+	}
+	for _, key := range injectedKeys(injectedMetadata) {
+		_, _ = fmt.Fprintf(hash, "metadata %s=%v\n", key, injectedMetadata[key])
+	}
+
+	return prefix + hex.EncodeToString(hash.Sum(nil))
+}
+
+// newCachedResponse drops the request ID from the headers, the cached response is answered to other requests.
+func newCachedResponse(status int, header http.Header, body []byte) *cachedResponse {
+	header.Del(RequestIDHeader)
+	sum := sha256.Sum256(body)
+
+	return &cachedResponse{
+		Status: status,
+		Header: header,
+		Body:   body,
+		ETag:   `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+}
+
+// cacheDirectives returns the directives of the Cache-Control of the response, the backend sets it via the
+// cache-control metadata, which is answered as the Grpc-Metadata-Cache-Control header.
+func cacheDirectives(header http.Header) []string {
+	var directives []string
+	for _, value := range append(header.Values("Cache-Control"), header.Values("Grpc-Metadata-Cache-Control")...) {
+		for _, directive := range strings.Split(value, ",") {
+			directives = append(directives, strings.ToLower(strings.TrimSpace(directive)))
+		}
+	}
+
+	return directives
+}
+
+// shareable reports whether the response of a request with Authorization can be kept by a shared cache, it has to be
+// public, s-maxage or must-revalidate, see RFC 9111, section 3.5.
+func shareable(header http.Header) bool {
+	for _, directive := range cacheDirectives(header) {
+		name, _, _ := strings.Cut(directive, "=")
+		switch name {
+		case "public", "s-maxage", "must-revalidate":
+			return true
+		}
+	}
+
+	return false
+}
+
+// cacheTTL parses the Cache-Control of the response, it isn't cached if it's no-store, no-cache or private.
+func cacheTTL(header http.Header, def time.Duration) (time.Duration, bool) {
+	directives := cacheDirectives(header)
+	if len(directives) == 0 {
+		return def, def > 0
+	}
+
+	// s-maxage overrides max-age for shared caches, -1 means the directive isn't set.
+	maxAge, sharedMaxAge := -1, -1
+	for _, directive := range directives {
+		name, arg, _ := strings.Cut(directive, "=")
+		switch name {
+		case "no-store", "no-cache", "private":
+			return 0, false
+		case "max-age", "s-maxage":
+			seconds, err := strconv.Atoi(strings.Trim(arg, `"`))
+			if err != nil || seconds < 0 {
+				continue
+			}
+			if name == "max-age" {
+				maxAge = seconds
+			} else {
+				sharedMaxAge = seconds
+			}
+		}
+	}
+
+	switch {
+	case sharedMaxAge >= 0:
+		return time.Duration(sharedMaxAge) * time.Second, sharedMaxAge > 0
+	case maxAge >= 0:
+		return time.Duration(maxAge) * time.Second, maxAge > 0
+	default:
+		return def, def > 0
+	}
+}
+
+// etagMatches reports whether If-None-Match contains the ETag, the weak comparison is used as RFC 9110 requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, value := range strings.Split(ifNoneMatch, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || strings.TrimPrefix(value, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MemoryCacheStore keeps the responses in an in-memory LRU, it's the default store of gateway.cache. The least
+// recently used response is evicted once gateway.cache.size responses are kept.
+type MemoryCacheStore struct {
+	mu      sync.Mutex
+	size    int
+	items   map[string]*list.Element
+	recency *list.List
+}
+
+type memoryCacheItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewMemoryCacheStore(size int) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		size:    size,
+		items:   make(map[string]*list.Element),
+		recency: list.New(),
+	}
+}
+
+func (r *MemoryCacheStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element, exist := r.items[key]
+	if !exist {
+		return nil, false, nil
+	}
+
+	item := element.Value.(*memoryCacheItem)
+	if time.Now().After(item.expires) {
+		r.recency.Remove(element)
+		delete(r.items, key)
+
+		return nil, false, nil
+	}
+
+	r.recency.MoveToFront(element)
+
+	return item.value, true, nil
+}
+
+func (r *MemoryCacheStore) Put(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if element, exist := r.items[key]; exist {
+		element.Value = &memoryCacheItem{key: key, value: value, expires: time.Now().Add(ttl)}
+		r.recency.MoveToFront(element)
+
+		return nil
+	}
+
+	r.items[key] = r.recency.PushFront(&memoryCacheItem{key: key, value: value, expires: time.Now().Add(ttl)})
+	for r.size > 0 && r.recency.Len() > r.size {
+		oldest := r.recency.Back()
+		r.recency.Remove(oldest)
+		delete(r.items, oldest.Value.(*memoryCacheItem).key)
+	}
+
+	return nil
+}
+
+// CacheFacadeStore keeps the responses in a store of the Goravel cache facade, for example,
+// gateway.NewCacheFacadeStore(facades.Cache().Store("redis")).
+type CacheFacadeStore struct {
+	driver contractscache.Driver
+}
+
+func NewCacheFacadeStore(driver contractscache.Driver) *CacheFacadeStore {
+	return &CacheFacadeStore{driver: driver}
+}
+
+func (r *CacheFacadeStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value := r.driver.WithContext(ctx).GetString(key)
+	if value == "" {
+		return nil, false, nil
+	}
+
+	return []byte(value), true, nil
+}
+
+func (r *CacheFacadeStore) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.driver.WithContext(ctx).Put(key, string(value), ttl)
+}
@@ -0,0 +1,257 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	cachemocks "github.com/goravel/framework/mocks/cache"
+	configmocks "github.com/goravel/framework/mocks/config"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/goravel/gateway/proto/example"
+)
+
+func TestResponseCache(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:4014")
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	users := NewUserController()
+	example.RegisterUserServiceServer(server, users)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	facadesConfig := FacadesConfig
+	FacadesConfig = mockConfig
+	defer func() {
+		FacadesConfig = facadesConfig
+	}()
+
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return ctx.Response().Data(HTTPStatusFromError(err), "text/plain", []byte(err.Error()))
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(true)
+	mockConfig.On("GetInt", "gateway.cache.size", 1000).Return(10)
+	mockConfig.On("GetInt", "gateway.cache.ttl", 0).Return(0)
+	mockConfig.On("Get", "gateway.cache.store").Return(nil)
+	mockConfig.On("Get", "gateway.cache.headers").Return([]string{"accept-language", "authorization"})
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(false)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "example").Return(newTestConnection(t, "127.0.0.1:4014"), nil)
+
+	require.Nil(t, gateway.Run())
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	get := func(url string, header map[string]string, inject ...func(ctx contractshttp.Context)) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+
+		ctx := NewTestContext(context.Background(), recorder, req)
+		for _, inject := range inject {
+			inject(ctx)
+		}
+		require.Nil(t, Get(ctx).Render())
+
+		return recorder
+	}
+	cacheable := map[string]string{"Grpc-Metadata-Cache-Control": "max-age=60"}
+
+	miss := get("/users?name=goravel&age=18", cacheable)
+	assert.Equal(t, http.StatusOK, miss.Code)
+	assert.Equal(t, "MISS", miss.Header().Get(CacheHeader))
+	assert.NotEmpty(t, miss.Header().Get("ETag"))
+	assert.Equal(t, int32(1), users.getUsersCalls.Load())
+
+	// The query is normalized.
+	hit := get("/users?age=18&name=goravel", cacheable)
+	assert.Equal(t, http.StatusOK, hit.Code)
+	assert.Equal(t, "HIT", hit.Header().Get(CacheHeader))
+	assert.Equal(t, miss.Header().Get("ETag"), hit.Header().Get("ETag"))
+	assert.Equal(t, "goravel", hit.Header().Get("Grpc-Metadata-Custom-Header"))
+	assert.Equal(t, miss.Body.String(), hit.Body.String())
+	assert.Equal(t, int32(1), users.getUsersCalls.Load())
+
+	notModified := get("/users?name=goravel&age=18", map[string]string{"If-None-Match": `"other", ` + miss.Header().Get("ETag")})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, int32(1), users.getUsersCalls.Load())
+
+	// The headers of gateway.cache.headers and the injected values are parts of the key.
+	assert.Equal(t, "MISS", get("/users?name=goravel&age=18", map[string]string{"Accept-Language": "en"}).Header().Get(CacheHeader))
+	assert.Equal(t, "MISS", get("/users?name=goravel&age=18", nil, func(ctx contractshttp.Context) {
+		Inject(ctx, "user_id", 3)
+	}).Header().Get(CacheHeader))
+	assert.Equal(t, int32(3), users.getUsersCalls.Load())
+
+	// The injected metadata is a part of the key, the value supplied by the client is used if the key is overridable.
+	for _, test := range []struct {
+		userID       int
+		header       map[string]string
+		expectHeader string
+	}{
+		{1, cacheable, "MISS"},
+		{2, cacheable, "MISS"},
+		{1, cacheable, "HIT"},
+		{1, map[string]string{"Grpc-Metadata-Cache-Control": "max-age=60", "Grpc-Metadata-Locale": "fr"}, "MISS"},
+	} {
+		assert.Equal(t, test.expectHeader, get("/users?name=goravel&age=18", test.header, func(ctx contractshttp.Context) {
+			InjectMetadata(ctx, "user-id", test.userID)
+			InjectMetadata(ctx, "locale", "en", Overridable())
+		}).Header().Get(CacheHeader))
+	}
+	assert.Equal(t, int32(6), users.getUsersCalls.Load())
+
+	// The response of a request with Authorization is only cached if it's public, s-maxage or must-revalidate, and it
+	// isn't shared among the requests of different Authorization.
+	for _, test := range []struct {
+		authorization string
+		cacheControl  string
+		expectHeader  string
+	}{
+		{"Bearer 1", "max-age=60", "MISS"},
+		{"Bearer 1", "max-age=60", "MISS"},
+		{"Bearer 1", "public, max-age=60", "MISS"},
+		{"Bearer 2", "public, max-age=60", "MISS"},
+		{"Bearer 1", "public, max-age=60", "HIT"},
+		{"Bearer 2", "public, max-age=60", "HIT"},
+		{"Bearer 3", "s-maxage=60", "MISS"},
+		{"Bearer 3", "s-maxage=60", "HIT"},
+	} {
+		assert.Equal(t, test.expectHeader, get("/users?name=authorized", map[string]string{
+			"Authorization":               test.authorization,
+			"Grpc-Metadata-Cache-Control": test.cacheControl,
+		}).Header().Get(CacheHeader))
+	}
+	assert.Equal(t, int32(11), users.getUsersCalls.Load())
+
+	// The response isn't cached if it's no-store, or if there is no Cache-Control and gateway.cache.ttl is 0.
+	for _, header := range []map[string]string{{"Grpc-Metadata-Cache-Control": "no-store"}, nil} {
+		for range 2 {
+			assert.Equal(t, "MISS", get("/users?name=none", header).Header().Get(CacheHeader))
+		}
+	}
+	assert.Equal(t, int32(15), users.getUsersCalls.Load())
+
+	mockGrpc.AssertExpectations(t)
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    http.Header
+		def       time.Duration
+		expectTTL time.Duration
+		expectOk  bool
+	}{
+		{"Default", http.Header{}, time.Minute, time.Minute, true},
+		{"Default is 0", http.Header{}, 0, 0, false},
+		{"max-age", http.Header{"Cache-Control": {"public, max-age=10"}}, time.Minute, 10 * time.Second, true},
+		{"s-maxage overrides max-age", http.Header{"Grpc-Metadata-Cache-Control": {"s-maxage=20, max-age=10"}}, 0, 20 * time.Second, true},
+		{"max-age is 0", http.Header{"Cache-Control": {"max-age=0"}}, time.Minute, 0, false},
+		{"no-store", http.Header{"Grpc-Metadata-Cache-Control": {"max-age=10", "no-store"}}, time.Minute, 0, false},
+		{"private", http.Header{"Cache-Control": {"Private"}}, time.Minute, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ttl, ok := cacheTTL(test.header, test.def)
+			assert.Equal(t, test.expectTTL, ttl)
+			assert.Equal(t, test.expectOk, ok)
+		})
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`"a"`, `"a"`))
+	assert.True(t, etagMatches(`"b", W/"a"`, `"a"`))
+	assert.True(t, etagMatches("*", `"a"`))
+	assert.False(t, etagMatches(`"b"`, `"a"`))
+	assert.False(t, etagMatches("", `"a"`))
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+	ctx := context.Background()
+
+	assert.Nil(t, store.Put(ctx, "a", []byte("a"), time.Minute))
+	assert.Nil(t, store.Put(ctx, "b", []byte("b"), time.Minute))
+
+	// a is used recently, so b is evicted.
+	value, exist, err := store.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, []byte("a"), value)
+	assert.Nil(t, store.Put(ctx, "c", []byte("c"), time.Minute))
+
+	_, exist, _ = store.Get(ctx, "b")
+	assert.False(t, exist)
+	_, exist, _ = store.Get(ctx, "c")
+	assert.True(t, exist)
+
+	// The expired value is removed.
+	assert.Nil(t, store.Put(ctx, "a", []byte("a"), -time.Second))
+	_, exist, _ = store.Get(ctx, "a")
+	assert.False(t, exist)
+	assert.NotContains(t, store.items, "a")
+}
+
+func TestCacheFacadeStore(t *testing.T) {
+	mockDriver := new(cachemocks.Driver)
+	store := NewCacheFacadeStore(mockDriver)
+	ctx := context.Background()
+
+	mockDriver.On("WithContext", ctx).Return(mockDriver)
+	mockDriver.On("Put", "a", "value", time.Minute).Return(nil).Once()
+	mockDriver.On("GetString", "a").Return("value").Once()
+	mockDriver.On("GetString", "b").Return("").Once()
+
+	assert.Nil(t, store.Put(ctx, "a", []byte("value"), time.Minute))
+
+	value, exist, err := store.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, []byte("value"), value)
+
+	_, exist, err = store.Get(ctx, "b")
+	assert.Nil(t, err)
+	assert.False(t, exist)
+
+	mockDriver.AssertExpectations(t)
+}
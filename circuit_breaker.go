@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// errorDomain is the domain of the google.rpc.ErrorInfo details added by the Gateway.
+	errorDomain       = "gateway.goravel.dev"
+	circuitOpenReason = "CIRCUIT_OPEN"
+)
+
+type CircuitState string
+
+const (
+	// CircuitClosed lets the requests through, the failures are counted.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects the requests without sending them to the backend, until gateway.circuit_breaker.open_timeout
+	// is passed.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets gateway.circuit_breaker.half_open_requests requests through to probe the backend, the
+	// circuit is closed if all of them succeed, and opened again if any of them fails.
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitEvent is passed to gateway.circuit_breaker.listener when the state of the circuit breaker of a backend
+// changes.
+type CircuitEvent struct {
+	// Backend is the name of the server in grpc.servers.
+	Backend string
+	From    CircuitState
+	To      CircuitState
+	Reason  string
+}
+
+// circuitBreakerOptions is the gateway.circuit_breaker configuration, it's nil if the circuit breaker is disabled.
+type circuitBreakerOptions struct {
+	consecutiveFailures int
+	errorRate           float64
+	minRequests         int
+	window              time.Duration
+	openTimeout         time.Duration
+	halfOpenRequests    int
+	listener            func(event CircuitEvent)
+}
+
+// circuitBreaker tracks the responses of a backend, a response with a 5xx status is a failure. The circuit is opened
+// if consecutiveFailures failures happen in a row, or if the failure ratio of the window reaches errorRate.
+type circuitBreaker struct {
+	backend string
+	options circuitBreakerOptions
+
+	mu          sync.Mutex
+	state       CircuitState
+	consecutive int
+	requests    int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+func newCircuitBreaker(backend string, options *circuitBreakerOptions) *circuitBreaker {
+	if options == nil {
+		return nil
+	}
+
+	return &circuitBreaker{
+		backend:     backend,
+		options:     *options,
+		state:       CircuitClosed,
+		windowStart: time.Now(),
+	}
+}
+
+// allow reports whether a request can be sent to the backend, the circuit becomes half-open once the open timeout is
+// passed.
+func (r *circuitBreaker) allow() bool {
+	r.mu.Lock()
+	var event *CircuitEvent
+	defer func() {
+		r.mu.Unlock()
+		r.notify(event)
+	}()
+
+	switch r.state {
+	case CircuitOpen:
+		if time.Since(r.openedAt) < r.options.openTimeout {
+			return false
+		}
+
+		event = r.transit(CircuitHalfOpen, "the open timeout is passed")
+		r.probes = 1
+
+		return true
+	case CircuitHalfOpen:
+		if r.probes >= r.options.halfOpenRequests {
+			return false
+		}
+		r.probes++
+
+		return true
+	default:
+		return true
+	}
+}
+
+// record counts the response of a request allowed by allow.
+func (r *circuitBreaker) record(success bool) {
+	r.mu.Lock()
+	var event *CircuitEvent
+	defer func() {
+		r.mu.Unlock()
+		r.notify(event)
+	}()
+
+	switch r.state {
+	case CircuitHalfOpen:
+		if !success {
+			event = r.transit(CircuitOpen, "a probe request failed")
+			return
+		}
+
+		r.successes++
+		if r.successes >= r.options.halfOpenRequests {
+			event = r.transit(CircuitClosed, "the probe requests succeeded")
+		}
+	case CircuitClosed:
+		if time.Since(r.windowStart) >= r.options.window {
+			r.requests, r.failures, r.windowStart = 0, 0, time.Now()
+		}
+
+		r.requests++
+		if success {
+			r.consecutive = 0
+			return
+		}
+		r.failures++
+		r.consecutive++
+
+		if r.options.consecutiveFailures > 0 && r.consecutive >= r.options.consecutiveFailures {
+			event = r.transit(CircuitOpen, fmt.Sprintf("%d consecutive failures", r.consecutive))
+		} else if rate := float64(r.failures) / float64(r.requests); r.options.errorRate > 0 && r.requests >= r.options.minRequests && rate >= r.options.errorRate {
+			event = r.transit(CircuitOpen, fmt.Sprintf("the error rate is %.2f", rate))
+		}
+	}
+}
+
+// transit changes the state and resets the counters, it must be called with mu held.
+func (r *circuitBreaker) transit(state CircuitState, reason string) *CircuitEvent {
+	event := &CircuitEvent{Backend: r.backend, From: r.state, To: state, Reason: reason}
+
+	r.state = state
+	r.consecutive, r.requests, r.failures, r.windowStart = 0, 0, 0, time.Now()
+	r.probes, r.successes = 0, 0
+	if state == CircuitOpen {
+		r.openedAt = time.Now()
+	}
+
+	return event
+}
+
+func (r *circuitBreaker) notify(event *CircuitEvent) {
+	if event == nil {
+		return
+	}
+
+	message := fmt.Sprintf("[Gateway] gRPC %s backend circuit breaker is %s, %s", event.Backend, event.To, event.Reason)
+	if event.To == CircuitOpen {
+		color.Redln(message)
+	} else {
+		color.Greenln(message)
+	}
+
+	if r.options.listener != nil {
+		r.options.listener(*event)
+	}
+}
+
+// middleware rejects the requests with Unavailable if the circuit is open, the status contains a google.rpc.ErrorInfo
+// detail so that the controller can pass a *CircuitOpenError to the fallback.
+func (r *circuitBreaker) middleware(mux *runtime.ServeMux, next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		if !r.allow() {
+			st := status.New(codes.Unavailable, (&CircuitOpenError{Backend: r.backend}).Error())
+			if detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+				Reason:   circuitOpenReason,
+				Domain:   errorDomain,
+				Metadata: map[string]string{"backend": r.backend},
+			}); err == nil {
+				st = detailed
+			}
+
+			_, outbound := runtime.MarshalerForRequest(mux, req)
+			runtime.HTTPError(req.Context(), mux, outbound, w, req, st.Err())
+			return
+		}
+
+		recorder := newResponseRecorder(w)
+		next(recorder, req, params)
+		r.record(recorder.status < http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,192 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	configmocks "github.com/goravel/framework/mocks/config"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/goravel/gateway/proto/example"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var events []CircuitEvent
+	options := &circuitBreakerOptions{
+		consecutiveFailures: 2,
+		errorRate:           0.5,
+		minRequests:         4,
+		window:              time.Minute,
+		openTimeout:         10 * time.Millisecond,
+		halfOpenRequests:    2,
+		listener: func(event CircuitEvent) {
+			events = append(events, event)
+		},
+	}
+
+	t.Run("Open after consecutive failures", func(t *testing.T) {
+		events = nil
+		breaker := newCircuitBreaker("user", options)
+
+		breaker.record(false)
+		assert.True(t, breaker.allow())
+		breaker.record(false)
+		assert.False(t, breaker.allow())
+		assert.Equal(t, []CircuitEvent{
+			{Backend: "user", From: CircuitClosed, To: CircuitOpen, Reason: "2 consecutive failures"},
+		}, events)
+	})
+
+	t.Run("Open if the error rate is reached", func(t *testing.T) {
+		events = nil
+		breaker := newCircuitBreaker("user", options)
+
+		for _, success := range []bool{true, false, true, false} {
+			assert.True(t, breaker.allow())
+			breaker.record(success)
+		}
+		assert.False(t, breaker.allow())
+		assert.Equal(t, []CircuitEvent{
+			{Backend: "user", From: CircuitClosed, To: CircuitOpen, Reason: "the error rate is 0.50"},
+		}, events)
+	})
+
+	t.Run("Close if the probe requests succeed", func(t *testing.T) {
+		events = nil
+		breaker := newCircuitBreaker("user", options)
+		breaker.record(false)
+		breaker.record(false)
+
+		time.Sleep(options.openTimeout)
+		assert.True(t, breaker.allow())
+		assert.True(t, breaker.allow())
+		// Only half_open_requests requests are let through.
+		assert.False(t, breaker.allow())
+		breaker.record(true)
+		breaker.record(true)
+		assert.True(t, breaker.allow())
+
+		assert.Equal(t, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}, circuitStates(events))
+	})
+
+	t.Run("Open again if a probe request fails", func(t *testing.T) {
+		events = nil
+		breaker := newCircuitBreaker("user", options)
+		breaker.record(false)
+		breaker.record(false)
+
+		time.Sleep(options.openTimeout)
+		assert.True(t, breaker.allow())
+		breaker.record(false)
+		assert.False(t, breaker.allow())
+
+		assert.Equal(t, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen}, circuitStates(events))
+	})
+}
+
+func TestCircuitBreakerMiddleware(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:4012")
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	users := NewUserController()
+	example.RegisterUserServiceServer(server, users)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	facadesConfig := FacadesConfig
+	FacadesConfig = mockConfig
+	defer func() {
+		FacadesConfig = facadesConfig
+	}()
+
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return nil
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(true)
+	mockConfig.On("GetInt", "gateway.circuit_breaker.consecutive_failures", 5).Return(2)
+	mockConfig.On("GetInt", "gateway.circuit_breaker.min_requests", 20).Return(20)
+	mockConfig.On("GetInt", "gateway.circuit_breaker.window", 10).Return(10)
+	mockConfig.On("GetInt", "gateway.circuit_breaker.open_timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.circuit_breaker.half_open_requests", 1).Return(1)
+	mockConfig.On("Get", "gateway.circuit_breaker.error_rate").Return(nil)
+	mockConfig.On("Get", "gateway.circuit_breaker.listener").Return(nil)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(false)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "example").Return(newTestConnection(t, "127.0.0.1:4012"), nil)
+
+	require.Nil(t, gateway.Run())
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	users.failures.Store(3)
+	for range 2 {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+		statusErr := newStatusError(recorder.Code, recorder.Body.Bytes(), nil)
+		assert.Equal(t, codes.Unavailable, statusErr.Code)
+		assert.False(t, errors.Is(statusErr, ErrCircuitOpen))
+	}
+
+	// The request isn't sent to the backend, the remaining failure isn't consumed.
+	recorder := httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, int32(1), users.failures.Load())
+
+	statusErr := newStatusError(recorder.Code, recorder.Body.Bytes(), nil)
+	assert.Equal(t, codes.Unavailable, statusErr.Code)
+	assert.True(t, errors.Is(statusErr, ErrCircuitOpen))
+	var circuitErr *CircuitOpenError
+	require.True(t, errors.As(statusErr, &circuitErr))
+	assert.Equal(t, "example", circuitErr.Backend)
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatusFromError(statusErr))
+
+	mockGrpc.AssertExpectations(t)
+}
+
+func circuitStates(events []CircuitEvent) []CircuitState {
+	var states []CircuitState
+	for _, event := range events {
+		states = append(states, event.To)
+	}
+
+	return states
+}
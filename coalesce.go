@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/textproto"
+	"net/url"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+// coalesceOptions is the gateway.coalesce configuration, it's nil if the coalescing is disabled.
+type coalesceOptions struct {
+	headers []string
+}
+
+// coalescer sends the identical GET requests of the controller that are in flight at the same time to the Gateway
+// once, the response is shared among them.
+type coalescer struct {
+	group    singleflight.Group
+	headers  []string
+	requests *prometheus.CounterVec
+}
+
+// coalescedResponse is the response shared by the requests of a key.
+type coalescedResponse struct {
+	resp *http.Response
+	data []byte
+}
+
+// newCoalescer returns nil if the coalescing is disabled, a nil *coalescer doesn't coalesce anything.
+func newCoalescer(options *coalesceOptions) *coalescer {
+	if options == nil {
+		return nil
+	}
+
+	headers := make([]string, 0, len(options.headers))
+	for _, header := range options.headers {
+		headers = append(headers, textproto.CanonicalMIMEHeaderKey(header))
+	}
+
+	return &coalescer{
+		headers: headers,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_coalesced_requests_total",
+			Help: "The total number of GET requests of the controller by result, leader requests are sent to the Gateway and coalesced requests share their responses.",
+		}, []string{"result"}),
+	}
+}
+
+// key identifies a request by the path, the normalized query, the headers of gateway.coalesce.headers, the injected
+// values and the injected metadata, so the requests of different users are never coalesced.
+func (r *coalescer) key(ctx contractshttp.Context, query url.Values, injected, injectedMetadata map[string]any) string {
+	return requestKey("gateway:coalesce:", ctx, query, r.headers, injected, injectedMetadata)
+}
+
+// do calls send once for the requests of key that are in flight at the same time, coalesced is true if the response
+// is sent by another request. A request stops waiting when its context is done, and it's sent on its own if the
+// request that sends it is canceled or times out, or the response is a stream, which can only be read once.
+func (r *coalescer) do(ctx context.Context, key string, send func() (*http.Response, []byte, error)) (resp *http.Response, data []byte, coalesced bool, err error) {
+	leader := false
+	results := r.group.DoChan(key, func() (any, error) {
+		leader = true
+		resp, data, err := send()
+		// The Gateway in the current process answers a canceled or timed out request with an error response, e.g. 499,
+		// it belongs to the request that sends it only.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if resp != nil && resp.Body != nil {
+				_ = resp.Body.Close()
+			}
+
+			return &coalescedResponse{}, ctxErr
+		}
+
+		return &coalescedResponse{resp: resp, data: data}, err
+	})
+
+	select {
+	case result := <-results:
+		shared := result.Val.(*coalescedResponse)
+		switch {
+		case leader:
+			r.requests.WithLabelValues("leader").Inc()
+		case ctx.Err() == nil && (errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded) ||
+			shared.resp != nil && isStream(shared.resp)):
+			r.requests.WithLabelValues("leader").Inc()
+			resp, data, err := send()
+
+			return resp, data, false, err
+		default:
+			r.requests.WithLabelValues("coalesced").Inc()
+
+			return sharedResponse(shared.resp), shared.data, true, result.Err
+		}
+
+		return shared.resp, shared.data, false, result.Err
+	case <-ctx.Done():
+		// The body of a stream is only read by the request that sends it, it's closed once the request gives up, so
+		// the Gateway doesn't block on writing it.
+		go func() {
+			result := <-results
+			if shared := result.Val.(*coalescedResponse); leader && shared.resp != nil && isStream(shared.resp) {
+				_ = shared.resp.Body.Close()
+			}
+		}()
+
+		return nil, nil, false, ctx.Err()
+	}
+}
+
+// sharedResponse copies the response for a request that shares it, the request id of the request that sent it is
+// dropped.
+func sharedResponse(resp *http.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+
+	shared := *resp
+	shared.Header = resp.Header.Clone()
+	shared.Header.Del(RequestIDHeader)
+
+	return &shared
+}
@@ -0,0 +1,291 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescer(t *testing.T) {
+	t.Run("Share the response", func(t *testing.T) {
+		coalescer := newCoalescer(&coalesceOptions{})
+
+		var calls atomic.Int32
+		started, release := make(chan struct{}), make(chan struct{})
+		send := func() (*http.Response, []byte, error) {
+			if calls.Add(1) == 1 {
+				close(started)
+			}
+			<-release
+
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{RequestIDHeader: {"1"}}}, []byte("goravel"), nil
+		}
+
+		var wg sync.WaitGroup
+		var coalescedCount atomic.Int32
+		do := func() {
+			defer wg.Done()
+
+			resp, data, coalesced, err := coalescer.do(context.Background(), "users", send)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, []byte("goravel"), data)
+			if coalesced {
+				coalescedCount.Add(1)
+				// The request id of the request that sent it isn't shared.
+				assert.Empty(t, resp.Header.Get(RequestIDHeader))
+			} else {
+				assert.Equal(t, "1", resp.Header.Get(RequestIDHeader))
+			}
+		}
+
+		wg.Add(1)
+		go do()
+		<-started
+		for range 3 {
+			wg.Add(1)
+			go do()
+		}
+		// Wait for the requests to join the leader.
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, int32(3), coalescedCount.Load())
+		assert.Equal(t, float64(1), testutil.ToFloat64(coalescer.requests.WithLabelValues("leader")))
+		assert.Equal(t, float64(3), testutil.ToFloat64(coalescer.requests.WithLabelValues("coalesced")))
+
+		// The request is sent again once the leader is done.
+		_, _, coalesced, err := coalescer.do(context.Background(), "users", func() (*http.Response, []byte, error) {
+			return &http.Response{StatusCode: http.StatusOK}, nil, nil
+		})
+		assert.Nil(t, err)
+		assert.False(t, coalesced)
+	})
+
+	t.Run("Send again if the leader is canceled", func(t *testing.T) {
+		coalescer := newCoalescer(&coalesceOptions{})
+
+		started, release, canceled := make(chan struct{}), make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(canceled)
+
+			_, _, _, _ = coalescer.do(context.Background(), "users", func() (*http.Response, []byte, error) {
+				close(started)
+				<-release
+
+				return nil, nil, context.Canceled
+			})
+		}()
+		<-started
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			resp, _, coalesced, err := coalescer.do(context.Background(), "users", func() (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil, nil
+			})
+			assert.Nil(t, err)
+			assert.False(t, coalesced)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		<-done
+		<-canceled
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(coalescer.requests.WithLabelValues("leader")))
+	})
+
+	t.Run("Send again if the leader is canceled or times out in-process", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			timeout   time.Duration
+			expectErr error
+		}{
+			{
+				name:      "canceled",
+				expectErr: context.Canceled,
+			},
+			{
+				name:      "timed out",
+				timeout:   100 * time.Millisecond,
+				expectErr: context.DeadlineExceeded,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				coalescer := newCoalescer(&coalesceOptions{})
+
+				// The Gateway answers a request whose context is done with an error response instead of an error.
+				started, release := make(chan struct{}), make(chan struct{})
+				var startOnce sync.Once
+				client := &http.Client{Transport: &inProcessTransport{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					startOnce.Do(func() {
+						close(started)
+					})
+
+					select {
+					case <-req.Context().Done():
+						w.WriteHeader(499)
+						_, _ = w.Write([]byte(`{"code":1,"message":"context canceled"}`))
+					case <-release:
+						_, _ = w.Write([]byte("goravel"))
+					}
+				})}}
+				sendWith := func(ctx context.Context) func() (*http.Response, []byte, error) {
+					return func() (*http.Response, []byte, error) {
+						req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/users", nil)
+						if err != nil {
+							return nil, nil, err
+						}
+
+						return send(client, req)
+					}
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				if test.timeout > 0 {
+					ctx, cancel = context.WithTimeout(context.Background(), test.timeout)
+				}
+				defer cancel()
+				leaderDone := make(chan struct{})
+				go func() {
+					defer close(leaderDone)
+
+					_, _, _, err := coalescer.do(ctx, "users", sendWith(ctx))
+					assert.ErrorIs(t, err, test.expectErr)
+				}()
+				<-started
+
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+
+					resp, data, coalesced, err := coalescer.do(context.Background(), "users", sendWith(context.Background()))
+					assert.Nil(t, err)
+					assert.False(t, coalesced)
+					assert.Equal(t, http.StatusOK, resp.StatusCode)
+					assert.Equal(t, []byte("goravel"), data)
+				}()
+				time.Sleep(50 * time.Millisecond)
+				if test.timeout == 0 {
+					cancel()
+				}
+				<-leaderDone
+				close(release)
+				<-done
+
+				// The leader stops waiting once its context is done, only the request sent again is counted.
+				assert.Equal(t, float64(1), testutil.ToFloat64(coalescer.requests.WithLabelValues("leader")))
+				assert.Equal(t, float64(0), testutil.ToFloat64(coalescer.requests.WithLabelValues("coalesced")))
+			})
+		}
+	})
+
+	t.Run("Send again if the response is a stream", func(t *testing.T) {
+		coalescer := newCoalescer(&coalesceOptions{})
+
+		started, release := make(chan struct{}), make(chan struct{})
+		go func() {
+			_, _, _, _ = coalescer.do(context.Background(), "users", func() (*http.Response, []byte, error) {
+				close(started)
+				<-release
+
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{streamHeader: {"true"}}}, nil, nil
+			})
+		}()
+		<-started
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			resp, _, coalesced, err := coalescer.do(context.Background(), "users", func() (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: http.StatusCreated}, nil, nil
+			})
+			assert.Nil(t, err)
+			assert.False(t, coalesced)
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		<-done
+	})
+
+	t.Run("Close the stream if the leader gives up", func(t *testing.T) {
+		coalescer := newCoalescer(&coalesceOptions{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+		body := &closeRecorder{Reader: strings.NewReader("")}
+		_, _, _, err := coalescer.do(ctx, "users", func() (*http.Response, []byte, error) {
+			cancel()
+			<-release
+
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{streamHeader: {"true"}}, Body: body}, nil, nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(release)
+		assert.Eventually(t, body.closed.Load, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		coalescer := newCoalescer(&coalesceOptions{})
+		ctx := NewTestContext(context.Background(), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+		key := coalescer.key(ctx, url.Values{}, nil, map[string]any{"user-id": 1})
+		assert.Equal(t, key, coalescer.key(ctx, url.Values{}, nil, map[string]any{"user-id": 1}))
+		assert.NotEqual(t, key, coalescer.key(ctx, url.Values{}, nil, map[string]any{"user-id": 2}))
+		assert.NotEqual(t, key, coalescer.key(ctx, url.Values{}, map[string]any{"user-id": 1}, nil))
+	})
+
+	t.Run("Stop waiting if the context is done", func(t *testing.T) {
+		coalescer := newCoalescer(&coalesceOptions{})
+
+		started, release := make(chan struct{}), make(chan struct{})
+		defer close(release)
+		go func() {
+			_, _, _, _ = coalescer.do(context.Background(), "users", func() (*http.Response, []byte, error) {
+				close(started)
+				<-release
+
+				return nil, nil, nil
+			})
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, _, _, err := coalescer.do(ctx, "users", func() (*http.Response, []byte, error) {
+			return nil, nil, nil
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// closeRecorder records whether the body is closed.
+type closeRecorder struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed.Store(true)
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package gateway
+
+import "sync"
+
+var (
+	remoteComponentsOnce sync.Once
+	remoteComponents     *components
+)
+
+// components are built from the parsed configuration, the controller reads them instead of the config facade.
+type components struct {
+	config      *gatewayConfig
+	tracing     *tracing
+	accessLog   *accessLog
+	rateLimiter *rateLimiter
+	cache       *responseCache
+	coalescer   *coalescer
+}
+
+func newComponents(config *gatewayConfig) *components {
+	return &components{
+		config:      config,
+		tracing:     newTracing(config.tracing),
+		accessLog:   newAccessLog(config.accessLog),
+		rateLimiter: newRateLimiter(config.rateLimit),
+		cache:       newResponseCache(config.cache),
+		coalescer:   newCoalescer(config.coalesce),
+	}
+}
+
+// currentComponents returns the components of the Gateway running in the current process, or the components built
+// once if the requests are sent to a remote Gateway. The invalid options of the latter fall back to their defaults,
+// gateway:validate reports them.
+func currentComponents() *components {
+	if gateway := instance.Load(); gateway != nil {
+		gateway.mu.Lock()
+		components := gateway.components
+		gateway.mu.Unlock()
+
+		if components != nil {
+			return components
+		}
+	}
+
+	remoteComponentsOnce.Do(func() {
+		config, _ := parseConfig(FacadesConfig, nil)
+		remoteComponents = newComponents(config)
+	})
+
+	return remoteComponents
+}
@@ -0,0 +1,622 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goravel/framework/contracts/config"
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/spf13/cast"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/codes"
+)
+
+// Fallback is gateway.fallback, it builds the response when a request of the controller fails.
+type Fallback func(ctx contractshttp.Context, err error) contractshttp.Response
+
+// ConfigError is a problem of the configuration, Path is the config key, for example, grpc.servers.example.handlers.
+type ConfigError struct {
+	Path    string
+	Message string
+}
+
+func (r *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", r.Path, r.Message)
+}
+
+// gatewayConfig is the parsed gateway and grpc.servers configuration.
+type gatewayConfig struct {
+	host        string
+	port        string
+	tls         tlsOptions
+	fallback    Fallback
+	headers     *headerFilter
+	statusCodes map[codes.Code]int
+	timeout     time.Duration
+	retry       *retryPolicy
+	handlers    []Grpc
+
+	startup        string
+	startupTimeout time.Duration
+	health         healthOptions
+	metricsPath    string
+	circuitBreaker *circuitBreakerOptions
+	tracing        sdktrace.SpanExporter
+	accessLog      *accessLogOptions
+	rateLimit      *rateLimitOptions
+	cache          *cacheOptions
+	coalesce       *coalesceOptions
+}
+
+// configParser parses the configuration, the problems are collected instead of returned one by one, so that all of
+// them can be reported at once.
+type configParser struct {
+	config config.Config
+	errs   []error
+}
+
+func newConfigParser(config config.Config) *configParser {
+	return &configParser{config: config}
+}
+
+// parseConfig parses the whole configuration, registered are the handlers added by Gateway.Register.
+func parseConfig(config config.Config, registered []Grpc) (*gatewayConfig, error) {
+	parser := newConfigParser(config)
+	gatewayConfig := &gatewayConfig{}
+	gatewayConfig.host, gatewayConfig.port = parser.address()
+	gatewayConfig.tls = parser.tls()
+	gatewayConfig.fallback = parser.fallback()
+	gatewayConfig.headers = parser.headers()
+	gatewayConfig.statusCodes = parser.statusCodes()
+	gatewayConfig.timeout = parser.timeout()
+	gatewayConfig.retry = parser.retry()
+	gatewayConfig.handlers = append(parser.handlers(registered), registered...)
+	gatewayConfig.startup, gatewayConfig.startupTimeout = parser.connection()
+	gatewayConfig.health = parser.health()
+	gatewayConfig.metricsPath = parser.path("gateway.metrics.path")
+	gatewayConfig.circuitBreaker = parser.circuitBreaker()
+	gatewayConfig.tracing = parser.tracingExporter()
+	gatewayConfig.accessLog = parser.accessLog()
+	gatewayConfig.rateLimit = parser.rateLimit()
+	gatewayConfig.cache = parser.cache()
+	gatewayConfig.coalesce = parser.coalesce()
+
+	return gatewayConfig, parser.err()
+}
+
+func (r *configParser) fail(path, format string, args ...any) {
+	r.errs = append(r.errs, &ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *configParser) err() error {
+	return errors.Join(r.errs...)
+}
+
+func (r *configParser) address() (string, string) {
+	host := r.config.GetString("gateway.host")
+	port := r.config.GetString("gateway.port")
+	if host == "" && port != "" {
+		r.fail("gateway.host", "please initialize GATEWAY_HOST, it's required if gateway.port is set")
+	}
+	if host != "" && port == "" {
+		r.fail("gateway.port", "please initialize GATEWAY_PORT, it's required if gateway.host is set")
+	}
+
+	return host, port
+}
+
+func (r *configParser) tls() tlsOptions {
+	options := newTLSOptions(r.config)
+	if options.certFile == "" {
+		return options
+	}
+
+	if options.keyFile == "" {
+		r.fail("gateway.tls.key_file", "is required if gateway.tls.cert_file is set")
+	}
+	if _, err := options.minVersion(); err != nil {
+		r.fail("gateway.tls.min_version", "%s is invalid, it should be one of 1.0, 1.1, 1.2 and 1.3", options.minVersionName)
+	}
+
+	files := []struct {
+		path string
+		file string
+	}{
+		{"gateway.tls.cert_file", options.certFile},
+		{"gateway.tls.key_file", options.keyFile},
+		{"gateway.tls.ca_file", options.caFile},
+		{"gateway.tls.client_ca_file", options.clientCAFile},
+	}
+	for _, file := range files {
+		if file.file == "" {
+			continue
+		}
+		if _, err := os.Stat(file.file); err != nil {
+			r.fail(file.path, "read %s failed: %v", file.file, err)
+		}
+	}
+
+	return options
+}
+
+// connection returns the startup policy and the time to wait for the backends if the policy is fail_fast.
+func (r *configParser) connection() (string, time.Duration) {
+	startup := r.config.GetString("gateway.connection.startup")
+	switch startup {
+	case "", StartupDegraded:
+		return StartupDegraded, 0
+	case StartupFailFast:
+		timeout := r.config.GetInt("gateway.connection.timeout", 5)
+		if timeout <= 0 {
+			r.fail("gateway.connection.timeout", "should be greater than 0, got %d", timeout)
+		}
+
+		return StartupFailFast, time.Duration(timeout) * time.Second
+	default:
+		r.fail("gateway.connection.startup", "%s is invalid, it should be one of %s and %s", startup, StartupDegraded, StartupFailFast)
+		return StartupDegraded, 0
+	}
+}
+
+func (r *configParser) health() healthOptions {
+	options := healthOptions{
+		healthz: r.path("gateway.health.healthz"),
+		readyz:  r.path("gateway.health.readyz"),
+	}
+
+	if options.readyz != "" {
+		timeout := r.config.GetInt("gateway.health.timeout", 1)
+		if timeout <= 0 {
+			r.fail("gateway.health.timeout", "should be greater than 0, got %d", timeout)
+		}
+
+		options.timeout = time.Duration(timeout) * time.Second
+	}
+
+	return options
+}
+
+// path reads the path of an endpoint served by the Gateway, an empty path disables the endpoint.
+func (r *configParser) path(key string) string {
+	path := r.config.GetString(key)
+	if path != "" && !strings.HasPrefix(path, "/") {
+		r.fail(key, "%s should start with /", path)
+	}
+
+	return path
+}
+
+// circuitBreaker returns nil if gateway.circuit_breaker.enabled is false, the other options are only read if it's
+// enabled.
+func (r *configParser) circuitBreaker() *circuitBreakerOptions {
+	if !r.config.GetBool("gateway.circuit_breaker.enabled") {
+		return nil
+	}
+
+	options := &circuitBreakerOptions{
+		consecutiveFailures: r.config.GetInt("gateway.circuit_breaker.consecutive_failures", 5),
+		minRequests:         r.config.GetInt("gateway.circuit_breaker.min_requests", 20),
+		window:              time.Duration(r.config.GetInt("gateway.circuit_breaker.window", 10)) * time.Second,
+		openTimeout:         time.Duration(r.config.GetInt("gateway.circuit_breaker.open_timeout", 30)) * time.Second,
+		halfOpenRequests:    r.config.GetInt("gateway.circuit_breaker.half_open_requests", 1),
+	}
+
+	if options.consecutiveFailures < 0 {
+		r.fail("gateway.circuit_breaker.consecutive_failures", "should be greater than or equal to 0, got %d", options.consecutiveFailures)
+	}
+	if options.window <= 0 {
+		r.fail("gateway.circuit_breaker.window", "should be greater than 0, got %d", options.window/time.Second)
+	}
+	if options.openTimeout <= 0 {
+		r.fail("gateway.circuit_breaker.open_timeout", "should be greater than 0, got %d", options.openTimeout/time.Second)
+	}
+	if options.halfOpenRequests <= 0 {
+		r.fail("gateway.circuit_breaker.half_open_requests", "should be greater than 0, got %d", options.halfOpenRequests)
+	}
+
+	if value := r.config.Get("gateway.circuit_breaker.error_rate"); value != nil {
+		errorRate, err := cast.ToFloat64E(value)
+		if err != nil || errorRate < 0 || errorRate > 1 {
+			r.fail("gateway.circuit_breaker.error_rate", "should be a number between 0 and 1, got %v", value)
+		} else {
+			options.errorRate = errorRate
+		}
+	}
+
+	switch listener := r.config.Get("gateway.circuit_breaker.listener").(type) {
+	case nil:
+	case func(event CircuitEvent):
+		options.listener = listener
+	default:
+		r.fail("gateway.circuit_breaker.listener", "should be func(gateway.CircuitEvent), got %T", listener)
+	}
+
+	return options
+}
+
+// tracingExporter returns nil if gateway.tracing.exporter isn't set, the spans aren't recorded in that case.
+func (r *configParser) tracingExporter() sdktrace.SpanExporter {
+	switch exporter := r.config.Get("gateway.tracing.exporter").(type) {
+	case nil:
+		return nil
+	case sdktrace.SpanExporter:
+		return exporter
+	default:
+		r.fail("gateway.tracing.exporter", "should implement go.opentelemetry.io/otel/sdk/trace.SpanExporter, got %T", exporter)
+		return nil
+	}
+}
+
+// accessLog returns nil if gateway.access_log.enabled is false, the other options are only read if it's enabled.
+func (r *configParser) accessLog() *accessLogOptions {
+	if !r.config.GetBool("gateway.access_log.enabled") {
+		return nil
+	}
+
+	options := &accessLogOptions{
+		channel:    r.config.GetString("gateway.access_log.channel"),
+		sampleRate: 1,
+		redact:     make(map[string]bool),
+	}
+
+	if value := r.config.Get("gateway.access_log.sample_rate"); value != nil {
+		sampleRate, err := cast.ToFloat64E(value)
+		if err != nil || sampleRate < 0 || sampleRate > 1 {
+			r.fail("gateway.access_log.sample_rate", "should be a number between 0 and 1, got %v", value)
+		} else {
+			options.sampleRate = sampleRate
+		}
+	}
+
+	if value := r.config.Get("gateway.access_log.redact"); value != nil {
+		fields, ok := stringSlice(value)
+		if !ok {
+			r.fail("gateway.access_log.redact", "should be []string, got %T", value)
+		}
+		for _, field := range fields {
+			options.redact[field] = true
+		}
+	}
+
+	return options
+}
+
+// rateLimit returns nil if gateway.rate_limit.enabled is false, the other options are only read if it's enabled.
+func (r *configParser) rateLimit() *rateLimitOptions {
+	if !r.config.GetBool("gateway.rate_limit.enabled") {
+		return nil
+	}
+
+	options := &rateLimitOptions{}
+
+	switch store := r.config.Get("gateway.rate_limit.store").(type) {
+	case nil:
+	case RateLimitStore:
+		options.store = store
+	default:
+		r.fail("gateway.rate_limit.store", "should implement gateway.RateLimitStore, got %T", store)
+	}
+
+	value := r.config.Get("gateway.rate_limit.limits")
+	if value == nil {
+		return options
+	}
+
+	limits, ok := value.([]RateLimit)
+	if !ok {
+		r.fail("gateway.rate_limit.limits", "should be []gateway.RateLimit, got %T", value)
+		return options
+	}
+
+	for i, limit := range limits {
+		path := fmt.Sprintf("gateway.rate_limit.limits.%d", i)
+		valid := true
+		if limit.Route != "*" && !strings.HasPrefix(limit.Route, "/") {
+			r.fail(path+".route", "%s should start with / or be *", limit.Route)
+			valid = false
+		}
+		if limit.Rate <= 0 {
+			r.fail(path+".rate", "should be greater than 0, got %v", limit.Rate)
+			valid = false
+		}
+		if limit.Burst < 0 {
+			r.fail(path+".burst", "should be greater than or equal to 0, got %d", limit.Burst)
+			valid = false
+		}
+
+		if valid {
+			options.limits = append(options.limits, limit)
+		}
+	}
+
+	return options
+}
+
+// cache returns nil if gateway.cache.enabled is false, the other options are only read if it's enabled.
+func (r *configParser) cache() *cacheOptions {
+	if !r.config.GetBool("gateway.cache.enabled") {
+		return nil
+	}
+
+	options := &cacheOptions{
+		size: r.config.GetInt("gateway.cache.size", 1000),
+	}
+	if options.size <= 0 {
+		r.fail("gateway.cache.size", "should be greater than 0, got %d", options.size)
+	}
+
+	ttl := r.config.GetInt("gateway.cache.ttl", 0)
+	if ttl < 0 {
+		r.fail("gateway.cache.ttl", "should be greater than or equal to 0, got %d", ttl)
+	} else {
+		options.ttl = time.Duration(ttl) * time.Second
+	}
+
+	switch store := r.config.Get("gateway.cache.store").(type) {
+	case nil:
+	case CacheStore:
+		options.store = store
+	default:
+		r.fail("gateway.cache.store", "should implement gateway.CacheStore, got %T", store)
+	}
+
+	if value := r.config.Get("gateway.cache.headers"); value != nil {
+		headers, ok := stringSlice(value)
+		if !ok {
+			r.fail("gateway.cache.headers", "should be []string, got %T", value)
+		}
+		options.headers = headers
+	}
+
+	return options
+}
+
+// coalesce returns nil if gateway.coalesce.enabled is false, the other options are only read if it's enabled.
+func (r *configParser) coalesce() *coalesceOptions {
+	if !r.config.GetBool("gateway.coalesce.enabled") {
+		return nil
+	}
+
+	options := &coalesceOptions{}
+	if value := r.config.Get("gateway.coalesce.headers"); value != nil {
+		headers, ok := stringSlice(value)
+		if !ok {
+			r.fail("gateway.coalesce.headers", "should be []string, got %T", value)
+		}
+		options.headers = headers
+	}
+
+	return options
+}
+
+// fallback returns defaultFallback if gateway.fallback is invalid, the controller still responds in that case.
+func (r *configParser) fallback() Fallback {
+	switch fallback := r.config.Get("gateway.fallback").(type) {
+	case func(ctx contractshttp.Context, err error) contractshttp.Response:
+		return fallback
+	case Fallback:
+		return fallback
+	case nil:
+		r.fail("gateway.fallback", "is required")
+	default:
+		r.fail("gateway.fallback", "should be func(http.Context, error) http.Response, got %T", fallback)
+	}
+
+	return defaultFallback
+}
+
+// headers ignores the invalid options of gateway.headers, the default hop-by-hop headers are still denied.
+func (r *configParser) headers() *headerFilter {
+	filter := &headerFilter{
+		allow: canonicalHeaderSet(nil),
+		deny:  canonicalHeaderSet(defaultDenyHeaders),
+	}
+
+	value := r.config.Get("gateway.headers")
+	if value == nil {
+		return filter
+	}
+
+	options, ok := value.(map[string]any)
+	if !ok {
+		r.fail("gateway.headers", "should be map[string]any, got %T", value)
+		return filter
+	}
+
+	for _, key := range []string{"allow", "deny"} {
+		if options[key] == nil {
+			continue
+		}
+
+		headers, ok := stringSlice(options[key])
+		if !ok {
+			r.fail("gateway.headers."+key, "should be []string, got %T", options[key])
+			continue
+		}
+
+		if key == "allow" {
+			filter.allow = canonicalHeaderSet(headers)
+		} else {
+			filter.deny = canonicalHeaderSet(append(headers, defaultDenyHeaders...))
+		}
+	}
+
+	return filter
+}
+
+func (r *configParser) statusCodes() map[codes.Code]int {
+	value := r.config.Get("gateway.status_codes")
+	if value == nil {
+		return nil
+	}
+
+	statusCodes, ok := value.(map[codes.Code]int)
+	if !ok {
+		r.fail("gateway.status_codes", "should be map[codes.Code]int, got %T", value)
+		return nil
+	}
+
+	for code, statusCode := range statusCodes {
+		if statusCode < 100 || statusCode > 599 {
+			r.fail("gateway.status_codes."+code.String(), "%d is not a valid HTTP status", statusCode)
+		}
+	}
+
+	return statusCodes
+}
+
+// timeout returns the default timeout of the controller requests, 0 means no timeout.
+func (r *configParser) timeout() time.Duration {
+	timeout := r.config.GetInt("gateway.timeout", 30)
+	if timeout < 0 {
+		r.fail("gateway.timeout", "should be greater than or equal to 0, got %d", timeout)
+		return 0
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// retry returns the retry policy of the controller requests, the other options are only read if
+// gateway.retry.max_attempts is greater than 1.
+func (r *configParser) retry() *retryPolicy {
+	policy := &retryPolicy{maxAttempts: 1}
+
+	maxAttempts := r.config.GetInt("gateway.retry.max_attempts", 1)
+	if maxAttempts < 1 {
+		r.fail("gateway.retry.max_attempts", "should be greater than 0, got %d", maxAttempts)
+	}
+	if maxAttempts <= 1 {
+		return policy
+	}
+
+	policy.maxAttempts = maxAttempts
+	policy.backoff = r.milliseconds("gateway.retry.backoff", 100)
+	policy.maxBackoff = r.milliseconds("gateway.retry.max_backoff", 1000)
+
+	policy.codes = defaultRetryCodes
+	if value := r.config.Get("gateway.retry.codes"); value != nil {
+		retryCodes, ok := value.([]codes.Code)
+		if !ok {
+			r.fail("gateway.retry.codes", "should be []codes.Code, got %T", value)
+		} else {
+			policy.codes = retryCodes
+		}
+	}
+
+	policy.methods = defaultRetryMethods
+	if value := r.config.Get("gateway.retry.methods"); value != nil {
+		methods, ok := stringSlice(value)
+		if !ok {
+			r.fail("gateway.retry.methods", "should be []string, got %T", value)
+		} else {
+			policy.methods = methods
+		}
+	}
+
+	return policy
+}
+
+func (r *configParser) milliseconds(key string, def int) time.Duration {
+	milliseconds := r.config.GetInt(key, def)
+	if milliseconds < 0 {
+		r.fail(key, "should be greater than or equal to 0, got %d", milliseconds)
+		return 0
+	}
+
+	return time.Duration(milliseconds) * time.Millisecond
+}
+
+// handlers parses grpc.servers, a client can leave handlers empty if its handlers are registered by Gateway.Register.
+func (r *configParser) handlers(registered []Grpc) []Grpc {
+	value := r.config.Get("grpc.servers")
+	if value == nil {
+		return nil
+	}
+
+	clients, ok := value.(map[string]any)
+	if !ok {
+		r.fail("grpc.servers", "should be map[string]any, got %T", value)
+		return nil
+	}
+
+	registeredNames := make(map[string]bool, len(registered))
+	for _, grpcHandler := range registered {
+		registeredNames[grpcHandler.Name] = true
+	}
+
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var grpcHandlers []Grpc
+	for _, name := range names {
+		path := "grpc.servers." + name
+		if name == "" {
+			r.fail("grpc.servers", "the gRPC client name is required")
+			continue
+		}
+
+		params, ok := clients[name].(map[string]any)
+		if !ok {
+			r.fail(path, "should be map[string]any, got %T", clients[name])
+			continue
+		}
+
+		value, exist := params["handlers"]
+		if !exist {
+			if !registeredNames[name] {
+				r.fail(path+".handlers", "is required")
+			}
+			continue
+		}
+
+		handlers, ok := value.([]Handler)
+		if !ok {
+			r.fail(path+".handlers", "should be []gateway.Handler, got %T", value)
+			continue
+		}
+
+		for i, handler := range handlers {
+			if handler == nil {
+				r.fail(fmt.Sprintf("%s.handlers.%d", path, i), "can't be nil")
+				continue
+			}
+
+			grpcHandlers = append(grpcHandlers, Grpc{Name: name, Handler: handler})
+		}
+	}
+
+	return grpcHandlers
+}
+
+func stringSlice(value any) ([]string, bool) {
+	switch value := value.(type) {
+	case []string:
+		return value, true
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+
+			values = append(values, str)
+		}
+
+		return values, true
+	}
+
+	return nil, false
+}
+
+// defaultFallback is used if gateway.fallback is invalid.
+func defaultFallback(ctx contractshttp.Context, err error) contractshttp.Response {
+	return ctx.Response().String(HTTPStatusFromError(err), err.Error())
+}
@@ -3,22 +3,154 @@ package config
 import (
 	"github.com/goravel/framework/contracts/http"
 	"github.com/goravel/framework/facades"
+	"google.golang.org/grpc/codes"
+
+	"github.com/goravel/gateway"
 )
 
 func init() {
 	config := facades.Config()
 	config.Add("gateway", map[string]any{
-		// The Gateway host and port, the HTTP request wil be sent to this host.
+		// The Gateway host and port, the HTTP request wil be sent to this host if the Gateway doesn't run in the current
+		// process. Leave both of them empty to serve the Gateway in-process only, without a standalone listener.
 		"host": config.Env("GATEWAY_HOST", ""),
 		"port": config.Env("GATEWAY_PORT", ""),
+		// Serve the Gateway via HTTPS if cert_file and key_file are set, the controller sends requests to the Gateway
+		// via HTTPS as well and presents the certificate as its client certificate.
+		"tls": map[string]any{
+			"cert_file": config.Env("GATEWAY_TLS_CERT_FILE", ""),
+			"key_file":  config.Env("GATEWAY_TLS_KEY_FILE", ""),
+			// The CA used by the controller to verify the Gateway, the system CAs will be used if it's empty.
+			"ca_file": config.Env("GATEWAY_TLS_CA_FILE", ""),
+			// The CA used by the Gateway to verify client certificates, enable mTLS if it's set.
+			"client_ca_file": config.Env("GATEWAY_TLS_CLIENT_CA_FILE", ""),
+			// Available: 1.0, 1.1, 1.2, 1.3
+			"min_version": config.Env("GATEWAY_TLS_MIN_VERSION", "1.2"),
+		},
+		// The gRPC connections are dialed lazily by the first request. If a backend is down, its routes answer 503 until
+		// it's available again, while the other backends keep working.
+		"connection": map[string]any{
+			// degraded: start the Gateway even if some backends are unreachable.
+			// fail_fast: Run returns an error if a backend isn't ready in timeout seconds.
+			"startup": config.Env("GATEWAY_CONNECTION_STARTUP", "degraded"),
+			"timeout": config.Env("GATEWAY_CONNECTION_TIMEOUT", 5),
+		},
+		// The health endpoints for load balancers, leave a path empty to disable it. healthz reports the Gateway is alive,
+		// readyz queries each backend with the grpc.health.v1 protocol and answers 503 if any of them isn't serving.
+		"health": map[string]any{
+			"healthz": "/healthz",
+			"readyz":  "/readyz",
+			// The timeout of the readiness checks, in seconds.
+			"timeout": 1,
+		},
+		// Track the responses of each server of grpc.servers, a 5xx response is a failure. The circuit of a server is
+		// opened after consecutive_failures failures in a row (0 disables it), or if the failure ratio of the last window
+		// seconds reaches error_rate once min_requests requests are sent. The requests of an open circuit are rejected
+		// with 503 until open_timeout seconds are passed, then half_open_requests requests probe the server. The listener
+		// is called with a gateway.CircuitEvent when the state of a circuit changes.
+		"circuit_breaker": map[string]any{
+			"enabled":              config.Env("GATEWAY_CIRCUIT_BREAKER_ENABLED", false),
+			"consecutive_failures": 5,
+			"error_rate":           0.5,
+			"min_requests":         20,
+			"window":               10,
+			"open_timeout":         30,
+			"half_open_requests":   1,
+			"listener":             nil,
+		},
+		// Limit the requests of the controller and the Gateway with token buckets, each limit matches an HTTP method
+		// (empty matches all methods) and a route pattern (/users/{id}, or * for all routes), and allows rate requests
+		// per second with bursts of burst requests. A client is identified by the IP, or by the value injected by
+		// gateway.Inject for the Identity key. The requests over a limit are answered with 429 and Retry-After. The
+		// buckets are kept in memory if store is nil, set a gateway.RateLimitStore to share them between the instances.
+		"rate_limit": map[string]any{
+			"enabled": config.Env("GATEWAY_RATE_LIMIT_ENABLED", false),
+			"store":   nil,
+			"limits": []gateway.RateLimit{
+				{Route: "*", Rate: 100, Burst: 200},
+			},
+		},
+		// Cache the successful responses of the GET requests of the controller, they are keyed by the path, the query, the
+		// request headers in headers and the injected values. A response is cached for the max-age of the Cache-Control
+		// that the backend sets via the cache-control metadata, or for ttl seconds if there is no Cache-Control (0 doesn't
+		// cache it). The responses are kept in an in-memory LRU of size responses if store is nil, set a
+		// gateway.CacheStore, for example, gateway.NewCacheFacadeStore(facades.Cache()), to share them. The responses of
+		// the requests with Authorization are only cached if they are public, s-maxage or must-revalidate.
+		"cache": map[string]any{
+			"enabled": config.Env("GATEWAY_CACHE_ENABLED", false),
+			"store":   nil,
+			"size":    1000,
+			"ttl":     0,
+			"headers": []string{"Authorization", "Accept", "Accept-Language"},
+		},
+		// Send the identical GET requests of the controller that are in flight at the same time to the Gateway once, and
+		// share the response among them. The requests are identical if they have the same path, query, injected values and
+		// request headers in headers. gateway_coalesced_requests_total of the metrics counts the leader and coalesced
+		// requests.
+		"coalesce": map[string]any{
+			"enabled": config.Env("GATEWAY_COALESCE_ENABLED", false),
+			"headers": []string{"Authorization", "Accept", "Accept-Language"},
+		},
+		// Expose the request counters, latency histograms and in-flight gauges in the Prometheus text format, they are
+		// labelled by the gRPC service and method, the HTTP method, the route and the status. Leave it empty to disable.
+		"metrics": map[string]any{
+			"path": "/metrics",
+		},
+		// The W3C traceparent and baggage headers are propagated from the client to the gRPC backend. Set an exporter to
+		// record the spans of the controller and the Gateway, it can be any go.opentelemetry.io/otel/sdk/trace.SpanExporter,
+		// for example, otlptracegrpc.New(ctx).
+		"tracing": map[string]any{
+			"exporter": nil,
+		},
+		// Write an entry for each request of the controller and the Gateway via the Log facade, the default channel is used
+		// if channel is empty. Only a sample_rate ratio (0 to 1) of the requests is written, but the failed requests (5xx)
+		// are always written. The fields in redact are replaced with [REDACTED], the injected values are never written.
+		"access_log": map[string]any{
+			"enabled":     config.Env("GATEWAY_ACCESS_LOG_ENABLED", false),
+			"channel":     "",
+			"sample_rate": 1,
+			"redact":      []string{},
+		},
 		// The fallback function will be called when the request is failed, you can optimize it to your response structure.
+		// If the gRPC endpoint returns an error, err is a *gateway.StatusError that contains the HTTP status, the
+		// original status answered by the gateway and the gRPC code.
 		"fallback": func(ctx http.Context, err error) http.Response {
-			return ctx.Response().Success().Json(map[string]any{
+			code := gateway.HTTPStatusFromError(err)
+
+			return ctx.Response().Json(code, map[string]any{
 				"status": map[string]any{
-					"code":  http.StatusInternalServerError,
-					"error": err.Error(),
+					"code":       code,
+					"error":      err.Error(),
+					"request_id": gateway.RequestID(ctx),
 				},
 			})
 		},
+		// All values of the request and response headers are proxied, except hop-by-hop headers (Connection,
+		// Transfer-Encoding, Host, etc.). Add sensitive headers that should never be proxied to deny, and add hop-by-hop
+		// headers that should be proxied anyway to allow.
+		"headers": map[string]any{
+			"allow": []string{},
+			"deny":  []string{},
+		},
+		// The timeout of the controller requests in seconds, 0 means no timeout. The deadline is sent to the gRPC backend
+		// via the Grpc-Timeout header, the timeout of a route can be overridden by gateway.Api.Timeout or gateway.Timeout.
+		// It isn't applied to the server-streaming methods registered by gateway.Routes.
+		"timeout": config.Env("GATEWAY_TIMEOUT", 30),
+		// Retry the controller requests that fail transiently: the Gateway can't be reached, or the gRPC backend returns
+		// one of codes. Only the methods in methods and the idempotent routes (gateway.Api.Idempotent, gateway.Idempotent
+		// or the IDEMPOTENT and NO_SIDE_EFFECTS idempotency_level of the proto) are retried, set max_attempts to 1 to
+		// disable it. The backoff doubles after each attempt up to max_backoff, in milliseconds, with a random jitter.
+		"retry": map[string]any{
+			"max_attempts": config.Env("GATEWAY_RETRY_MAX_ATTEMPTS", 3),
+			"backoff":      100,
+			"max_backoff":  1000,
+			"codes":        []codes.Code{codes.Unavailable},
+			"methods":      []string{"GET", "DELETE"},
+		},
+		// Map gRPC codes to HTTP status, the status answered by the gateway will be used if a code is not set.
+		"status_codes": map[codes.Code]int{
+			codes.NotFound:        http.StatusNotFound,
+			codes.InvalidArgument: http.StatusBadRequest,
+		},
 	})
 }
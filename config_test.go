@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	configmocks "github.com/goravel/framework/mocks/config"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestParseConfig(t *testing.T) {
+	handler := func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+		return nil
+	}
+
+	tests := []struct {
+		name          string
+		setup         func(mockConfig *configmocks.Config)
+		registered    []Grpc
+		expectErrs    []string
+		expectServers []string
+	}{
+		{
+			name: "Happy path",
+			setup: func(mockConfig *configmocks.Config) {
+				mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
+				mockConfig.On("GetString", "gateway.port").Return("3001")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+				mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+					return nil
+				})
+				mockConfig.On("Get", "gateway.headers").Return(map[string]any{
+					"allow": []string{"Upgrade"},
+				})
+				mockConfig.On("Get", "gateway.status_codes").Return(map[codes.Code]int{
+					codes.NotFound: http.StatusGone,
+				})
+				mockConfig.On("GetInt", "gateway.timeout", 30).Return(10)
+				mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(3)
+				mockConfig.On("GetInt", "gateway.retry.backoff", 100).Return(50)
+				mockConfig.On("GetInt", "gateway.retry.max_backoff", 1000).Return(500)
+				mockConfig.On("Get", "gateway.retry.codes").Return([]codes.Code{codes.Unavailable, codes.Aborted})
+				mockConfig.On("Get", "gateway.retry.methods").Return([]string{"GET", "POST"})
+				mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+					"user": map[string]any{
+						"handlers": []Handler{handler},
+					},
+					// The handlers of role are registered by Gateway.Register.
+					"role": map[string]any{},
+				})
+				mockConfig.On("GetString", "gateway.connection.startup").Return(StartupFailFast)
+				mockConfig.On("GetInt", "gateway.connection.timeout", 5).Return(3)
+				mockConfig.On("GetString", "gateway.health.healthz").Return("/healthz")
+				mockConfig.On("GetString", "gateway.health.readyz").Return("/readyz")
+				mockConfig.On("GetInt", "gateway.health.timeout", 1).Return(1)
+				mockConfig.On("GetString", "gateway.metrics.path").Return("/metrics")
+				mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(true)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.consecutive_failures", 5).Return(5)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.min_requests", 20).Return(20)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.window", 10).Return(10)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.open_timeout", 30).Return(30)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.half_open_requests", 1).Return(1)
+				mockConfig.On("Get", "gateway.circuit_breaker.error_rate").Return(0.5)
+				mockConfig.On("Get", "gateway.circuit_breaker.listener").Return(func(event CircuitEvent) {})
+				mockConfig.On("Get", "gateway.tracing.exporter").Return(tracetest.NewInMemoryExporter())
+				mockConfig.On("GetBool", "gateway.access_log.enabled").Return(true)
+				mockConfig.On("GetString", "gateway.access_log.channel").Return("gateway")
+				mockConfig.On("Get", "gateway.access_log.sample_rate").Return(0.5)
+				mockConfig.On("Get", "gateway.access_log.redact").Return([]string{"path"})
+				mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(true)
+				mockConfig.On("Get", "gateway.rate_limit.store").Return(NewMemoryRateLimitStore())
+				mockConfig.On("Get", "gateway.rate_limit.limits").Return([]RateLimit{
+					{Method: http.MethodPost, Route: "/users", Rate: 1, Burst: 5, Identity: "user_id"},
+				})
+				mockConfig.On("GetBool", "gateway.cache.enabled").Return(true)
+				mockConfig.On("GetInt", "gateway.cache.size", 1000).Return(1000)
+				mockConfig.On("GetInt", "gateway.cache.ttl", 0).Return(60)
+				mockConfig.On("Get", "gateway.cache.store").Return(NewMemoryCacheStore(10))
+				mockConfig.On("Get", "gateway.cache.headers").Return([]string{"Accept-Language"})
+				mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(true)
+				mockConfig.On("Get", "gateway.coalesce.headers").Return([]string{"Authorization"})
+			},
+			registered:    []Grpc{{Name: "role", Handler: handler}},
+			expectServers: []string{"user", "role"},
+		},
+		{
+			name: "Report all problems",
+			setup: func(mockConfig *configmocks.Config) {
+				mockConfig.On("GetString", "gateway.host").Return("")
+				mockConfig.On("GetString", "gateway.port").Return("3001")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("/not/exist.pem")
+				mockConfig.On("GetString", "gateway.tls.key_file").Return("")
+				mockConfig.On("GetString", "gateway.tls.ca_file").Return("")
+				mockConfig.On("GetString", "gateway.tls.client_ca_file").Return("")
+				mockConfig.On("GetString", "gateway.tls.min_version").Return("1.4")
+				mockConfig.On("Get", "gateway.fallback").Return("fallback")
+				mockConfig.On("Get", "gateway.headers").Return(map[string]any{
+					"deny": 1,
+				})
+				mockConfig.On("Get", "gateway.status_codes").Return(map[codes.Code]int{
+					codes.NotFound: 999,
+				})
+				mockConfig.On("GetInt", "gateway.timeout", 30).Return(-1)
+				mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(2)
+				mockConfig.On("GetInt", "gateway.retry.backoff", 100).Return(-1)
+				mockConfig.On("GetInt", "gateway.retry.max_backoff", 1000).Return(1000)
+				mockConfig.On("Get", "gateway.retry.codes").Return([]string{"Unavailable"})
+				mockConfig.On("Get", "gateway.retry.methods").Return(nil)
+				mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+					"user": map[string]any{
+						"handlers": []Handler{handler, nil},
+					},
+					"role": map[string]any{},
+					"tag":  "tag",
+				})
+				mockConfig.On("GetString", "gateway.connection.startup").Return("eager")
+				mockConfig.On("GetString", "gateway.health.healthz").Return("healthz")
+				mockConfig.On("GetString", "gateway.health.readyz").Return("")
+				mockConfig.On("GetString", "gateway.metrics.path").Return("")
+				mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(true)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.consecutive_failures", 5).Return(-1)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.min_requests", 20).Return(20)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.window", 10).Return(0)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.open_timeout", 30).Return(30)
+				mockConfig.On("GetInt", "gateway.circuit_breaker.half_open_requests", 1).Return(1)
+				mockConfig.On("Get", "gateway.circuit_breaker.error_rate").Return("half")
+				mockConfig.On("Get", "gateway.circuit_breaker.listener").Return("log")
+				mockConfig.On("Get", "gateway.tracing.exporter").Return("otlp")
+				mockConfig.On("GetBool", "gateway.access_log.enabled").Return(true)
+				mockConfig.On("GetString", "gateway.access_log.channel").Return("")
+				mockConfig.On("Get", "gateway.access_log.sample_rate").Return(2)
+				mockConfig.On("Get", "gateway.access_log.redact").Return("path")
+				mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(true)
+				mockConfig.On("Get", "gateway.rate_limit.store").Return("redis")
+				mockConfig.On("Get", "gateway.rate_limit.limits").Return([]RateLimit{
+					{Route: "users", Rate: 0, Burst: -1},
+				})
+				mockConfig.On("GetBool", "gateway.cache.enabled").Return(true)
+				mockConfig.On("GetInt", "gateway.cache.size", 1000).Return(0)
+				mockConfig.On("GetInt", "gateway.cache.ttl", 0).Return(-1)
+				mockConfig.On("Get", "gateway.cache.store").Return("redis")
+				mockConfig.On("Get", "gateway.cache.headers").Return("Accept-Language")
+				mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(true)
+				mockConfig.On("Get", "gateway.coalesce.headers").Return("Authorization")
+			},
+			expectErrs: []string{
+				"gateway.host: please initialize GATEWAY_HOST, it's required if gateway.port is set",
+				"gateway.tls.key_file: is required if gateway.tls.cert_file is set",
+				"gateway.tls.min_version: 1.4 is invalid, it should be one of 1.0, 1.1, 1.2 and 1.3",
+				"gateway.tls.cert_file: read /not/exist.pem failed: stat /not/exist.pem: no such file or directory",
+				"gateway.fallback: should be func(http.Context, error) http.Response, got string",
+				"gateway.headers.deny: should be []string, got int",
+				"gateway.status_codes.NotFound: 999 is not a valid HTTP status",
+				"gateway.timeout: should be greater than or equal to 0, got -1",
+				"gateway.retry.backoff: should be greater than or equal to 0, got -1",
+				"gateway.retry.codes: should be []codes.Code, got []string",
+				"grpc.servers.role.handlers: is required",
+				"grpc.servers.tag: should be map[string]any, got string",
+				"grpc.servers.user.handlers.1: can't be nil",
+				"gateway.connection.startup: eager is invalid, it should be one of degraded and fail_fast",
+				"gateway.health.healthz: healthz should start with /",
+				"gateway.circuit_breaker.consecutive_failures: should be greater than or equal to 0, got -1",
+				"gateway.circuit_breaker.window: should be greater than 0, got 0",
+				"gateway.circuit_breaker.error_rate: should be a number between 0 and 1, got half",
+				"gateway.circuit_breaker.listener: should be func(gateway.CircuitEvent), got string",
+				"gateway.tracing.exporter: should implement go.opentelemetry.io/otel/sdk/trace.SpanExporter, got string",
+				"gateway.access_log.sample_rate: should be a number between 0 and 1, got 2",
+				"gateway.access_log.redact: should be []string, got string",
+				"gateway.rate_limit.store: should implement gateway.RateLimitStore, got string",
+				"gateway.rate_limit.limits.0.route: users should start with / or be *",
+				"gateway.rate_limit.limits.0.rate: should be greater than 0, got 0",
+				"gateway.rate_limit.limits.0.burst: should be greater than or equal to 0, got -1",
+				"gateway.cache.size: should be greater than 0, got 0",
+				"gateway.cache.ttl: should be greater than or equal to 0, got -1",
+				"gateway.cache.store: should implement gateway.CacheStore, got string",
+				"gateway.cache.headers: should be []string, got string",
+				"gateway.coalesce.headers: should be []string, got string",
+			},
+			expectServers: []string{"user"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockConfig := new(configmocks.Config)
+			test.setup(mockConfig)
+
+			gatewayConfig, err := parseConfig(mockConfig, test.registered)
+			if len(test.expectErrs) == 0 {
+				assert.Nil(t, err)
+			} else {
+				var errs []string
+				for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+					var configErr *ConfigError
+					assert.ErrorAs(t, err, &configErr)
+					errs = append(errs, configErr.Error())
+				}
+				assert.Equal(t, test.expectErrs, errs)
+			}
+
+			var servers []string
+			for _, grpcHandler := range gatewayConfig.handlers {
+				servers = append(servers, grpcHandler.Name)
+			}
+			assert.Equal(t, test.expectServers, servers)
+			assert.NotNil(t, gatewayConfig.fallback)
+
+			mockConfig.AssertExpectations(t)
+		})
+	}
+}
@@ -1,14 +1,24 @@
 package gateway
 
 import (
-	"context"
+	"time"
 
 	"github.com/goravel/framework/contracts/http"
-	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
-	"google.golang.org/grpc"
 )
 
-const InjectKey = "gateway-inject"
+const (
+	InjectKey         = "gateway-inject"
+	InjectMetadataKey = "gateway-inject-metadata"
+	RequestIDKey      = "gateway-request-id"
+	TimeoutKey        = "gateway-timeout"
+	IdempotentKey     = "gateway-idempotent"
+
+	// RequestIDHeader correlates the request of the client, the request sent to the Gateway and the gRPC call, it's
+	// forwarded to the backend as the x-request-id metadata.
+	RequestIDHeader = "X-Request-Id"
+
+	injectOverridableSuffix = "-overridable"
+)
 
 type NumberOrString interface {
 	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64 | ~string
@@ -18,9 +28,17 @@ type Api struct {
 	Method     string
 	Url        string
 	Middleware []http.Middleware
+	// Timeout overrides gateway.timeout for the route if it's greater than 0.
+	Timeout time.Duration
+	// Idempotent lets gateway.retry retry the route whatever its HTTP method is, the routes of methods with the
+	// IDEMPOTENT or NO_SIDE_EFFECTS idempotency_level are idempotent by default.
+	Idempotent bool
+	// stream is true if the route is a server-streaming method, gateway.timeout isn't applied to it.
+	stream bool
 }
 
+// Grpc is a handler of the gRPC client Name, the handler registers the HTTP endpoints of a service to the ServeMux.
 type Grpc struct {
 	Name    string
-	Handler func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+	Handler Handler
 }
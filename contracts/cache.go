@@ -0,0 +1,15 @@
+package contracts
+
+import (
+	"context"
+	"time"
+)
+
+// CacheStore keeps the responses cached by gateway.cache, implement it to share the cache between the instances of the
+// application.
+type CacheStore interface {
+	// Get returns the value of key, exist is false if the key doesn't exist or is expired.
+	Get(ctx context.Context, key string) (value []byte, exist bool, err error)
+	// Put stores the value of key, it expires after ttl.
+	Put(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
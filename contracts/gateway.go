@@ -1,9 +1,23 @@
 package contracts
 
 import (
+	"context"
+
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
 )
 
+// Handler registers the HTTP endpoints of a gRPC service to the ServeMux, for example, RegisterUserServiceHandler of
+// the generated files.
+type Handler func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
 type Gateway interface {
+	// Register adds handlers of the gRPC client name, they are merged with the handlers of grpc.servers.
+	Register(name string, handlers ...Handler) error
+	// Run registers the gRPC handlers and serves the Gateway.
 	Run(mux ...*runtime.ServeMux) error
+	// Validate returns all problems of the gateway and grpc.servers configuration.
+	Validate() error
+	// Shutdown gracefully stops the Gateway and closes the gRPC connections.
+	Shutdown(ctx context.Context) error
 }
@@ -0,0 +1,14 @@
+package contracts
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitStore keeps the token buckets of gateway.rate_limit, implement it to share the buckets between the
+// instances of the application, for example, with Redis.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key, rate is the number of tokens added per second and burst is the size
+	// of the bucket. If there is no token, allowed is false and retryAfter is the time until the next token is added.
+	Take(ctx context.Context, key string, rate float64, burst int) (allowed bool, retryAfter time.Duration, err error)
+}
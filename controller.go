@@ -1,14 +1,17 @@
 package gateway
 
 import (
-	"fmt"
+	"bytes"
+	"errors"
 	"io"
 	"net/http"
-	"strings"
+	"time"
 
 	contractshttp "github.com/goravel/framework/contracts/http"
-	"github.com/goravel/framework/foundation/json"
-	"github.com/spf13/cast"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
 )
 
 func Get(ctx contractshttp.Context) contractshttp.Response {
@@ -32,73 +35,241 @@ func Patch(ctx contractshttp.Context) contractshttp.Response {
 }
 
 func request(ctx contractshttp.Context, method string) contractshttp.Response {
-	// Inject Value into Query
-	if injectValue, exist := ctx.Value(InjectKey).(map[string]any); exist {
-		query := ctx.Request().Origin().URL.Query()
-		for key, value := range injectValue {
-			query.Add(key, cast.ToString(value))
+	components := currentComponents()
+	gatewayConfig := components.config
+	fallback := gatewayConfig.fallback
+	timeout := gatewayConfig.timeout
+	retry := gatewayConfig.retry
+	requestID := withRequestID(ctx)
+
+	entry := &accessLogEntry{
+		source:    "controller",
+		method:    method,
+		path:      ctx.Request().Path(),
+		grpcCode:  codes.OK,
+		start:     time.Now(),
+		requestID: requestID,
+	}
+	// The context, the span and the access log entry of a stream are finished once the stream ends.
+	var streaming bool
+	accessLog := components.accessLog
+	defer func() {
+		if !streaming {
+			accessLog.write(entry)
 		}
-		ctx.Request().Origin().URL.RawQuery = query.Encode()
+	}()
+
+	fail := func(err error) contractshttp.Response {
+		entry.fail(err)
+
+		return fallback(ctx, err)
 	}
 
-	fallback := FacadesConfig.Get("gateway.fallback").(func(ctx contractshttp.Context, err error) contractshttp.Response)
+	injected, err := injectedValues(ctx, InjectKey)
+	if err != nil {
+		return fail(err)
+	}
+	entry.injected = injectedKeys(injected)
 
-	var body io.Reader
+	var rateLimitErr *RateLimitError
+	if err := components.rateLimiter.controller(ctx, method, injected); errors.As(err, &rateLimitErr) {
+		ctx.Response().Header("Retry-After", retryAfterSeconds(rateLimitErr.RetryAfter))
+		return fail(err)
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+		merged      bool
+	)
 	if method != http.MethodGet && method != http.MethodDelete {
-		// Put Query into Body, because Gateway only accept Body
-		data, err := io.ReadAll(ctx.Request().Origin().Body)
+		body, contentType, merged, err = newBody(ctx, injected)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	// The body is buffered to be sent again if the request can be retried.
+	retryable := retry.allows(ctx, method)
+	if retryable && body != nil {
+		data, err := io.ReadAll(body)
 		if err != nil {
-			return fallback(ctx, err)
+			return fail(err)
 		}
+		body = bytes.NewReader(data)
+	}
+
+	// The values supplied by the client for the injected keys are always stripped from queries, the injected values
+	// are added to queries if they aren't in the body.
+	query := ctx.Request().Origin().URL.Query()
+	if err := injectQuery(ctx, query, injected, !merged); err != nil {
+		return fail(err)
+	}
 
-		jsonDriver := json.New()
-		var dataJson map[string]any
-		if err := jsonDriver.Unmarshal(data, &dataJson); err != nil {
-			return fallback(ctx, err)
+	// The injected metadata can carry the identity of the user, it's a part of the cache and coalescing keys.
+	var injectedMetadata map[string]any
+	if method == http.MethodGet {
+		if injectedMetadata, err = injectedMetadataValues(ctx); err != nil {
+			return fail(err)
 		}
+	}
 
-		for key, value := range ctx.Request().Queries() {
-			dataJson[key] = value
+	// Only the GET requests are cached, the key contains the query built above.
+	cache := components.cache
+	var cacheKey string
+	// A shared cache keeps the responses of the requests with Authorization only if they allow it, see RFC 9111.
+	authorized := ctx.Request().Header("Authorization") != ""
+	if cache != nil && method == http.MethodGet {
+		cacheKey = cache.key(ctx, query, injected, injectedMetadata)
+		if cached := cache.get(ctx.Context(), cacheKey, authorized); cached != nil {
+			return cache.respond(ctx, entry, cached, true)
 		}
+	}
 
-		newData, err := jsonDriver.Marshal(dataJson)
-		if err != nil {
-			return fallback(ctx, err)
+	reqCtx, cancel := requestContext(ctx, timeout)
+	reqCtx = rateLimited(reqCtx)
+
+	tracing := components.tracing
+	spanCtx, span := tracing.startProxy(reqCtx, ctx.Request().Headers(), method, ctx.Request().Path())
+	finish := func() {
+		span.End()
+		cancel()
+	}
+	defer func() {
+		if !streaming {
+			finish()
 		}
+	}()
 
-		body = strings.NewReader(string(newData))
+	client, url, err := newTransport(gatewayConfig, ctx.Request().Path())
+	if err != nil {
+		return fail(err)
 	}
 
-	url := fmt.Sprintf("http://%s:%s%s", FacadesConfig.GetString("gateway.host"), FacadesConfig.GetString("gateway.port"), ctx.Request().Path())
-	gatewayReq, err := http.NewRequest(method, url, body)
+	gatewayReq, err := http.NewRequestWithContext(spanCtx, method, url, body)
 	if err != nil {
-		return fallback(ctx, err)
+		return fail(err)
 	}
 
-	query := ctx.Request().Origin().URL.Query()
 	gatewayReq.URL.RawQuery = query.Encode()
-	for key, header := range ctx.Request().Headers() {
-		gatewayReq.Header.Set(key, header[0])
+	headerFilter := gatewayConfig.headers
+	headerFilter.copyHeaders(gatewayReq.Header, ctx.Request().Headers())
+	if contentType != "" {
+		gatewayReq.Header.Set("Content-Type", contentType)
+	}
+	gatewayReq.Header.Set(RequestIDHeader, requestID)
+	// Ask the Gateway to mark the responses of server-streaming methods.
+	gatewayReq.Header.Set(streamHeader, "true")
+	if err := injectMetadata(ctx, gatewayReq.Header); err != nil {
+		return fail(err)
 	}
+	tracing.inject(spanCtx, gatewayReq.Header)
 
-	gatewayResp, err := http.DefaultClient.Do(gatewayReq)
+	attempts := func() (*http.Response, []byte, error) {
+		for attempt := 1; ; attempt++ {
+			gatewayResp, data, err := send(client, gatewayReq)
+			if !retryable || attempt >= retry.maxAttempts || !retry.retryable(gatewayResp, data, err) {
+				return gatewayResp, data, err
+			}
+			if retry.wait(spanCtx, attempt) != nil {
+				return gatewayResp, data, err
+			}
+			span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt+1)))
+		}
+	}
+
+	// The identical GET requests in flight at the same time share the attempts of one of them.
+	var (
+		gatewayResp *http.Response
+		data        []byte
+	)
+	if coalescer := components.coalescer; coalescer != nil && method == http.MethodGet {
+		var coalesced bool
+		gatewayResp, data, coalesced, err = coalescer.do(spanCtx, coalescer.key(ctx, query, injected, injectedMetadata), attempts)
+		if coalesced {
+			span.AddEvent("coalesced")
+		}
+	} else {
+		gatewayResp, data, err = attempts()
+	}
 	if err != nil {
-		return fallback(ctx, err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return fail(err)
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", gatewayResp.StatusCode))
+
+	// The request id is already set by withRequestID, and the stream marker is only read by the controller.
+	resp := ctx.Response()
+	respHeader := gatewayResp.Header.Clone()
+	respHeader.Del(RequestIDHeader)
+	respHeader.Del(streamHeader)
+	headerFilter.copyHeaders(resp.Writer().Header(), respHeader)
+
+	if gatewayResp.StatusCode < http.StatusOK || gatewayResp.StatusCode >= http.StatusMultipleChoices {
+		return fail(newStatusError(gatewayResp.StatusCode, data, gatewayConfig.statusCodes))
+	}
+
+	if isStream(gatewayResp) {
+		streaming = true
+
+		return stream(ctx, gatewayResp, entry, func() {
+			finish()
+			accessLog.write(entry)
+		})
+	}
+
+	responseContentType := gatewayResp.Header.Get("Content-Type")
+	if responseContentType == "" {
+		responseContentType = MIMEJSON
+	}
+
+	if cacheKey != "" {
+		header := make(http.Header)
+		headerFilter.copyHeaders(header, gatewayResp.Header)
+		cached := newCachedResponse(gatewayResp.StatusCode, header, data)
+		cache.put(ctx.Context(), cacheKey, cached, authorized)
+
+		return cache.respond(ctx, entry, cached, false)
+	}
+
+	entry.status = gatewayResp.StatusCode
+	entry.bytes = len(data)
+
+	return resp.Data(gatewayResp.StatusCode, responseContentType, data)
+}
+
+// send sends an attempt of the request and reads the response, the body of the request is rewound and the time left
+// before the deadline is updated for each attempt.
+func send(client *http.Client, gatewayReq *http.Request) (*http.Response, []byte, error) {
+	attemptReq := gatewayReq.Clone(gatewayReq.Context())
+	if deadline, ok := attemptReq.Context().Deadline(); ok {
+		attemptReq.Header.Set("Grpc-Timeout", grpcTimeout(time.Until(deadline)))
+	}
+	if gatewayReq.GetBody != nil {
+		body, err := gatewayReq.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		attemptReq.Body = body
+	}
+
+	gatewayResp, err := client.Do(attemptReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The body of a stream is read by the controller as the messages arrive.
+	if isStream(gatewayResp) {
+		return gatewayResp, nil, nil
 	}
 	defer func() {
 		_ = gatewayResp.Body.Close()
 	}()
+
 	data, err := io.ReadAll(gatewayResp.Body)
 	if err != nil {
-		return fallback(ctx, err)
-	}
-
-	resp := ctx.Response()
-	for key, value := range gatewayResp.Header {
-		if len(value) > 0 && key != "Content-Length" {
-			resp = resp.Header(key, value[0])
-		}
+		return nil, nil, err
 	}
 
-	return resp.Data(200, ctx.Request().Header("Content-Type", "application/json"), data)
+	return gatewayResp, data, nil
 }
@@ -1,11 +1,15 @@
 package gateway
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,13 +18,15 @@ import (
 	contractsession "github.com/goravel/framework/contracts/session"
 	"github.com/goravel/framework/contracts/validation"
 	frameworkgrpc "github.com/goravel/framework/grpc"
-	mocksconfig "github.com/goravel/framework/mocks/config"
 	testingmock "github.com/goravel/framework/testing/mock"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/goravel/gateway/proto/example"
 )
@@ -38,6 +44,11 @@ type ControllerTestSuite struct {
 	suite.Suite
 	grpc    *frameworkgrpc.Application
 	gateway *Gateway
+	users   *UserController
+	// components are built by Run, a test changes a copy of their configuration via configure.
+	components *components
+	// inject injects extra values to the requests of a test.
+	inject func(ctx contractshttp.Context)
 }
 
 func TestControllerTestSuite(t *testing.T) {
@@ -50,6 +61,24 @@ func (s *ControllerTestSuite) SetupSuite() {
 	mockConfig.EXPECT().GetString("grpc.port").Return(examplePort).Once()
 	mockConfig.EXPECT().GetString("gateway.host").Return(gatewayHost).Once()
 	mockConfig.EXPECT().GetString("gateway.port").Return(gatewayPort).Once()
+	mockConfig.EXPECT().GetString("gateway.tls.cert_file").Return("").Once()
+	mockConfig.EXPECT().Get("gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return ctx.Response().Data(HTTPStatusFromError(err), "text/plain", []byte(err.Error()))
+	}).Once()
+	mockConfig.EXPECT().Get("gateway.headers").Return(nil).Once()
+	mockConfig.EXPECT().Get("gateway.status_codes").Return(nil).Once()
+	mockConfig.EXPECT().GetInt("gateway.timeout", 30).Return(30).Once()
+	mockConfig.EXPECT().GetInt("gateway.retry.max_attempts", 1).Return(1).Once()
+	mockConfig.EXPECT().GetString("gateway.connection.startup").Return("").Once()
+	mockConfig.EXPECT().GetString("gateway.health.healthz").Return("").Once()
+	mockConfig.EXPECT().GetString("gateway.health.readyz").Return("").Once()
+	mockConfig.EXPECT().GetString("gateway.metrics.path").Return("").Once()
+	mockConfig.EXPECT().GetBool("gateway.circuit_breaker.enabled").Return(false).Once()
+	mockConfig.EXPECT().Get("gateway.tracing.exporter").Return(nil).Once()
+	mockConfig.EXPECT().GetBool("gateway.access_log.enabled").Return(false).Once()
+	mockConfig.EXPECT().GetBool("gateway.rate_limit.enabled").Return(false).Once()
+	mockConfig.EXPECT().GetBool("gateway.cache.enabled").Return(false).Once()
+	mockConfig.EXPECT().GetBool("gateway.coalesce.enabled").Return(false).Once()
 	mockConfig.EXPECT().Get("grpc.servers").Return(map[string]any{
 		"example": map[string]any{
 			"host":         exampleHost,
@@ -66,7 +95,8 @@ func (s *ControllerTestSuite) SetupSuite() {
 	s.grpc = frameworkgrpc.NewApplication(mockConfig)
 	s.grpc.UnaryServerInterceptors([]grpc.UnaryServerInterceptor{})
 	s.grpc.UnaryClientInterceptorGroups(map[string][]grpc.UnaryClientInterceptor{})
-	example.RegisterUserServiceServer(s.grpc.Server(), NewUserController())
+	s.users = NewUserController()
+	example.RegisterUserServiceServer(s.grpc.Server(), s.users)
 
 	go func() {
 		if err := s.grpc.Run(); err != nil {
@@ -78,6 +108,7 @@ func (s *ControllerTestSuite) SetupSuite() {
 
 	go func() {
 		mux := runtime.NewServeMux(
+			runtime.WithMarshalerOption(MIMEProtobuf, &runtime.ProtoMarshaller{}),
 			runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
 				MarshalOptions: protojson.MarshalOptions{
 					UseProtoNames: true,
@@ -97,9 +128,9 @@ func (s *ControllerTestSuite) SetupSuite() {
 
 		switch r.Method {
 		case "GET":
-			resp = Get(NewTestContext(context.Background(), w, r))
+			resp = Get(s.newTestContext(w, r))
 		case "POST":
-			resp = Post(NewTestContext(context.Background(), w, r))
+			resp = Post(s.newTestContext(w, r))
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -114,11 +145,11 @@ func (s *ControllerTestSuite) SetupSuite() {
 
 		switch r.Method {
 		case "GET":
-			resp = Get(NewTestContext(context.Background(), w, r))
+			resp = Get(s.newTestContext(w, r))
 		case "PUT":
-			resp = Put(NewTestContext(context.Background(), w, r))
+			resp = Put(s.newTestContext(w, r))
 		case "DELETE":
-			resp = Delete(NewTestContext(context.Background(), w, r))
+			resp = Delete(s.newTestContext(w, r))
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -137,6 +168,33 @@ func (s *ControllerTestSuite) SetupSuite() {
 	time.Sleep(1 * time.Second)
 
 	mockConfig.AssertExpectations(s.T())
+	s.components = currentComponents()
+}
+
+func (s *ControllerTestSuite) SetupTest() {
+	s.inject = nil
+	s.configure(func(config *gatewayConfig) {})
+}
+
+// configure changes a copy of the configuration parsed by Run, the changes of the previous test are discarded.
+func (s *ControllerTestSuite) configure(change func(config *gatewayConfig)) {
+	config := *s.components.config
+	change(&config)
+	components := *s.components
+	components.config = &config
+
+	s.gateway.mu.Lock()
+	s.gateway.components = &components
+	s.gateway.mu.Unlock()
+}
+
+func (s *ControllerTestSuite) newTestContext(w http.ResponseWriter, r *http.Request) *TestContext {
+	ctx := NewTestContext(context.Background(), w, r)
+	if s.inject != nil {
+		s.inject(ctx)
+	}
+
+	return ctx
 }
 
 func (s *ControllerTestSuite) TestGet() {
@@ -156,8 +214,6 @@ func (s *ControllerTestSuite) TestGet() {
 
 	for _, test := range tests {
 		s.Run(test.name, func() {
-			mockConfig := mockConfig()
-
 			req, err := http.NewRequest(http.MethodGet, test.path, nil)
 			s.Require().NoError(err)
 
@@ -178,15 +234,362 @@ func (s *ControllerTestSuite) TestGet() {
 			}
 
 			s.Equal(`{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"goravel","age":18}}`, strings.ReplaceAll(string(body), " ", ""))
+		})
+	}
+}
+
+func (s *ControllerTestSuite) TestGetWithoutInProcessGateway() {
+	instance.Store(nil)
+	defer instance.Store(s.gateway)
+	remoteComponentsOnce = sync.Once{}
+	remoteClientOnce = sync.Once{}
+
+	// The configuration is parsed once, the Gateway isn't running in the current process.
+	mockConfig := mockFactory.Config()
+	mockConfig.EXPECT().GetString("gateway.host").Return(gatewayHost).Once()
+	mockConfig.EXPECT().GetString("gateway.port").Return(gatewayPort).Once()
+	mockConfig.EXPECT().GetString("gateway.tls.cert_file").Return("").Once()
+	mockConfig.EXPECT().Get("grpc.servers").Return(nil).Once()
+	mockRunConfig(mockConfig)
+	facadesConfig := FacadesConfig
+	FacadesConfig = mockConfig
+	defer func() {
+		FacadesConfig = facadesConfig
+	}()
+
+	s.getWithoutInProcessGateway()
+	s.getWithoutInProcessGateway()
+}
+
+func (s *ControllerTestSuite) getWithoutInProcessGateway() {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%s/users/1", httpPort), nil)
+	s.Require().NoError(err)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Grpc-Metadata-Name", "goravel")
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+
+	s.Equal(`{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"goravel","age":18}}`, strings.ReplaceAll(string(body), " ", ""))
+}
 
-			mockConfig.AssertExpectations(s.T())
+func (s *ControllerTestSuite) TestHeaders() {
+	tests := []struct {
+		name       string
+		headers    map[string]any
+		expectTags []string
+		expectHop  []string
+	}{
+		{
+			name:       "Forward all values and skip hop-by-hop headers",
+			expectTags: []string{"goravel", "gateway"},
+		},
+		{
+			name: "Skip the headers of gateway.headers.deny",
+			headers: map[string]any{
+				"deny": []string{"Grpc-Metadata-Tag"},
+			},
+		},
+		{
+			name: "Forward the hop-by-hop headers of gateway.headers.allow",
+			headers: map[string]any{
+				"allow": []string{"Grpc-Metadata-Hop"},
+			},
+			expectTags: []string{"goravel", "gateway"},
+			expectHop:  []string{"1"},
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.configure(func(config *gatewayConfig) {
+				mockConfig := mockFactory.Config()
+				mockConfig.EXPECT().Get("gateway.headers").Return(test.headers).Once()
+				config.headers = newConfigParser(mockConfig).headers()
+			})
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%s/users/1", httpPort), nil)
+			s.Require().NoError(err)
+
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Add("Grpc-Metadata-Tag", "goravel")
+			req.Header.Add("Grpc-Metadata-Tag", "gateway")
+			req.Header.Set("Grpc-Metadata-Hop", "1")
+			req.Header.Set("Connection", "Grpc-Metadata-Hop")
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			s.Equal(http.StatusOK, resp.StatusCode)
+			s.Equal(test.expectTags, resp.Header.Values("Grpc-Metadata-Tags"))
+			s.Equal(test.expectHop, resp.Header.Values("Grpc-Metadata-Hop"))
 		})
 	}
 }
 
-func (s *ControllerTestSuite) TestPost() {
-	mockConfig := mockConfig()
+func (s *ControllerTestSuite) TestStatusCode() {
+	tests := []struct {
+		name         string
+		statusCodes  map[codes.Code]int
+		expectStatus int
+	}{
+		{
+			name:         "Use the status of gateway",
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name: "Use the status of gateway.status_codes",
+			statusCodes: map[codes.Code]int{
+				codes.NotFound: http.StatusGone,
+			},
+			expectStatus: http.StatusGone,
+		},
+	}
 
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.configure(func(config *gatewayConfig) {
+				config.statusCodes = test.statusCodes
+			})
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%s/users/0", httpPort), nil)
+			s.Require().NoError(err)
+
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			body, err := io.ReadAll(resp.Body)
+			s.Require().NoError(err)
+
+			s.Equal(test.expectStatus, resp.StatusCode)
+			s.Equal("gateway responded with status 404: user not found", string(body))
+		})
+	}
+}
+
+func (s *ControllerTestSuite) TestRequestID() {
+	tests := []struct {
+		name      string
+		path      string
+		requestID string
+		expectErr bool
+	}{
+		{
+			name:      "Forward the request id of the client",
+			path:      "/users/1",
+			requestID: "goravel",
+		},
+		{
+			name: "Generate the request id if the client doesn't send it",
+			path: "/users/1",
+		},
+		{
+			name:      "Pass the request id to the fallback",
+			path:      "/users/0",
+			requestID: "goravel",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.configure(func(config *gatewayConfig) {
+				config.fallback = func(ctx contractshttp.Context, err error) contractshttp.Response {
+					return ctx.Response().Data(HTTPStatusFromError(err), "text/plain", []byte(RequestID(ctx)))
+				}
+			})
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%s%s", httpPort, test.path), nil)
+			s.Require().NoError(err)
+
+			req.Header.Set("Content-Type", "application/json")
+			if test.requestID != "" {
+				req.Header.Set(RequestIDHeader, test.requestID)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			body, err := io.ReadAll(resp.Body)
+			s.Require().NoError(err)
+
+			// The request id echoed by the Gateway isn't copied again.
+			s.Len(resp.Header.Values(RequestIDHeader), 1)
+			requestID := resp.Header.Get(RequestIDHeader)
+			if test.requestID != "" {
+				s.Equal(test.requestID, requestID)
+			} else {
+				s.Len(requestID, 36)
+			}
+
+			if test.expectErr {
+				s.Equal(http.StatusNotFound, resp.StatusCode)
+				s.Equal(requestID, string(body))
+			} else {
+				s.Equal(http.StatusOK, resp.StatusCode)
+				s.Equal(requestID, resp.Header.Get("Grpc-Metadata-Request-Id"))
+			}
+		})
+	}
+}
+
+func (s *ControllerTestSuite) TestTimeout() {
+	tests := []struct {
+		name           string
+		timeout        time.Duration
+		expectStatus   int
+		expectDeadline string
+	}{
+		{
+			name:           "Propagate gateway.timeout to the backend",
+			expectStatus:   http.StatusOK,
+			expectDeadline: "true",
+		},
+		{
+			name:         "Override gateway.timeout by the route",
+			timeout:      time.Nanosecond,
+			expectStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:         "Disable the timeout by the route",
+			timeout:      -1,
+			expectStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			if test.timeout != 0 {
+				s.inject = func(ctx contractshttp.Context) {
+					Timeout(ctx, test.timeout)
+				}
+			}
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%s/users/1", httpPort), nil)
+			s.Require().NoError(err)
+
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			s.Equal(test.expectStatus, resp.StatusCode)
+			s.Equal(test.expectDeadline, resp.Header.Get("Grpc-Metadata-Deadline"))
+		})
+	}
+}
+
+func (s *ControllerTestSuite) TestRetry() {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		maxAttempts  int
+		failures     int32
+		idempotent   bool
+		expectStatus int
+	}{
+		{
+			name:         "Retry GET until it succeeds",
+			method:       http.MethodGet,
+			path:         "/users/1",
+			maxAttempts:  3,
+			failures:     2,
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "Give up after max attempts",
+			method:       http.MethodGet,
+			path:         "/users/1",
+			maxAttempts:  2,
+			failures:     2,
+			expectStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:         "Don't retry POST by default",
+			method:       http.MethodPost,
+			path:         "/users",
+			maxAttempts:  3,
+			failures:     1,
+			expectStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:         "Retry POST if it's idempotent",
+			method:       http.MethodPost,
+			path:         "/users",
+			maxAttempts:  3,
+			failures:     1,
+			idempotent:   true,
+			expectStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.users.failures.Store(test.failures)
+			defer s.users.failures.Store(0)
+
+			s.configure(func(config *gatewayConfig) {
+				config.retry = &retryPolicy{
+					maxAttempts: test.maxAttempts,
+					backoff:     time.Millisecond,
+					maxBackoff:  10 * time.Millisecond,
+					codes:       defaultRetryCodes,
+					methods:     defaultRetryMethods,
+				}
+			})
+			if test.idempotent {
+				s.inject = Idempotent
+			}
+
+			var body io.Reader
+			if test.method == http.MethodPost {
+				body = strings.NewReader(`{"name": "goravel", "age": 18}`)
+			}
+			req, err := http.NewRequest(test.method, fmt.Sprintf("http://127.0.0.1:%s%s", httpPort, test.path), body)
+			s.Require().NoError(err)
+
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			s.Equal(test.expectStatus, resp.StatusCode)
+			if test.method == http.MethodPost && test.expectStatus == http.StatusOK {
+				// The buffered body is sent again.
+				respBody, err := io.ReadAll(resp.Body)
+				s.Require().NoError(err)
+				s.Contains(string(respBody), `"name":"goravel"`)
+			}
+		})
+	}
+}
+
+func (s *ControllerTestSuite) TestPost() {
 	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%s/users", httpPort), strings.NewReader(`{
 		"name": "goravel",
 		"age": 18
@@ -207,13 +610,340 @@ func (s *ControllerTestSuite) TestPost() {
 	}
 
 	s.Equal(`{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"goravel","age":18}}`, strings.ReplaceAll(string(body), " ", ""))
+}
 
-	mockConfig.AssertExpectations(s.T())
+func (s *ControllerTestSuite) TestInject() {
+	s.inject = func(ctx contractshttp.Context) {
+		Inject(ctx, "role_ids", []int32{1, 2})
+		Inject(ctx, "tenant", &example.Tenant{Id: 3, Name: "goravel"})
+		Inject(ctx, "admin", true)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   io.Reader
+	}{
+		{
+			name:   "Happy path - query",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("http://127.0.0.1:%s/users?name=goravel&age=18", httpPort),
+		},
+		{
+			name:   "Happy path - body",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("http://127.0.0.1:%s/users", httpPort),
+			body:   strings.NewReader(`{"name": "goravel", "age": 18}`),
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			req, err := http.NewRequest(test.method, test.path, test.body)
+			s.Require().NoError(err)
+
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			body, err := io.ReadAll(resp.Body)
+			s.Require().NoError(err)
+
+			s.Equal(http.StatusOK, resp.StatusCode)
+			s.Equal(`{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"goravel","age":18,"role_ids":[1,2],"tenant":{"id":3,"name":"goravel"},"admin":true}}`, strings.ReplaceAll(string(body), " ", ""))
+		})
+	}
 }
 
-func (s *ControllerTestSuite) TestPut() {
-	mockConfig := mockConfig()
+func (s *ControllerTestSuite) TestInjectMetadata() {
+	tests := []struct {
+		name       string
+		inject     func(ctx contractshttp.Context)
+		path       string
+		expectBody string
+	}{
+		{
+			name: "Injected metadata wins",
+			inject: func(ctx contractshttp.Context) {
+				InjectMetadata(ctx, "name", "injected")
+			},
+			path:       "/users/1",
+			expectBody: `{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"injected","age":18}}`,
+		},
+		{
+			name: "Client metadata wins if the key is overridable",
+			inject: func(ctx contractshttp.Context) {
+				InjectMetadata(ctx, "name", "injected", Overridable())
+			},
+			path:       "/users/1",
+			expectBody: `{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"goravel","age":18}}`,
+		},
+		{
+			name: "Client query wins if the key is overridable",
+			inject: func(ctx contractshttp.Context) {
+				Inject(ctx, "age", 20, Overridable())
+				Inject(ctx, "name", "injected", Overridable())
+			},
+			path:       "/users?age=18",
+			expectBody: `{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"injected","age":18}}`,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.inject = test.inject
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%s%s", httpPort, test.path), nil)
+			s.Require().NoError(err)
+
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Grpc-Metadata-Name", "goravel")
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			body, err := io.ReadAll(resp.Body)
+			s.Require().NoError(err)
+
+			s.Equal(http.StatusOK, resp.StatusCode)
+			s.Equal(test.expectBody, strings.ReplaceAll(string(body), " ", ""))
+		})
+	}
+}
+
+func (s *ControllerTestSuite) TestInjectSpoofing() {
+	protobufBody, err := proto.Marshal(&example.CreateUserRequest{UserId: 5})
+	s.Require().NoError(err)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		contentType  string
+		body         string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			name:       "Query",
+			method:     http.MethodGet,
+			path:       "/users?user_id=5",
+			expectBody: `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3}}}`,
+		},
+		{
+			name:       "Repeated query",
+			method:     http.MethodGet,
+			path:       "/users?user_id=5&user_id=6",
+			expectBody: `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3}}}`,
+		},
+		{
+			name:       "camelCase query",
+			method:     http.MethodGet,
+			path:       "/users?userId=5",
+			expectBody: `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3}}}`,
+		},
+		{
+			name:       "Nested query",
+			method:     http.MethodGet,
+			path:       "/users?tenant.id=5&tenant.name=goravel",
+			expectBody: `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3,"name":"goravel"}}}`,
+		},
+		{
+			name:        "Body",
+			method:      http.MethodPost,
+			path:        "/users",
+			contentType: "application/json",
+			body:        `{"user_id": 5}`,
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3}}}`,
+		},
+		{
+			name:        "camelCase body",
+			method:      http.MethodPost,
+			path:        "/users",
+			contentType: "application/json",
+			body:        `{"userId": 5}`,
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3}}}`,
+		},
+		{
+			name:        "Nested body",
+			method:      http.MethodPost,
+			path:        "/users",
+			contentType: "application/json",
+			body:        `{"tenant": {"id": 5, "name": "goravel"}}`,
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3,"name":"goravel"}}}`,
+		},
+		{
+			name:        "Query of body",
+			method:      http.MethodPost,
+			path:        "/users?userId=5",
+			contentType: "application/json",
+			body:        `{}`,
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3}}}`,
+		},
+		{
+			name:        "null body",
+			method:      http.MethodPost,
+			path:        "/users?x=1",
+			contentType: "application/json",
+			body:        `null`,
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3}}}`,
+		},
+		{
+			name:        "Form",
+			method:      http.MethodPost,
+			path:        "/users",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "user_id=5&userId=6",
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2,"tenant":{"id":3}}}`,
+		},
+		{
+			name:         "Protobuf",
+			method:       http.MethodPost,
+			path:         "/users",
+			contentType:  "application/x-protobuf",
+			body:         string(protobufBody),
+			expectStatus: http.StatusUnsupportedMediaType,
+			expectBody:   ErrInjectProtobuf.Error(),
+		},
+		{
+			name:         "JSON array",
+			method:       http.MethodPost,
+			path:         "/users?user_id=5",
+			contentType:  "application/json",
+			body:         `[1, 2]`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   ErrInjectJSONArray.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.inject = func(ctx contractshttp.Context) {
+				Inject(ctx, "tenant.id", 3)
+			}
+
+			req, err := http.NewRequest(test.method, fmt.Sprintf("http://127.0.0.1:%s%s", httpPort, test.path), strings.NewReader(test.body))
+			s.Require().NoError(err)
+
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			body, err := io.ReadAll(resp.Body)
+			s.Require().NoError(err)
+
+			expectStatus := test.expectStatus
+			if expectStatus == 0 {
+				expectStatus = http.StatusOK
+			}
+			s.Equal(expectStatus, resp.StatusCode)
+			if expectStatus == http.StatusOK {
+				s.Equal(test.expectBody, strings.ReplaceAll(string(body), " ", ""))
+			} else {
+				s.Equal(test.expectBody, string(body))
+			}
+		})
+	}
+}
+
+func (s *ControllerTestSuite) TestPostWithContentType() {
+	multipartBody := &bytes.Buffer{}
+	multipartWriter := multipart.NewWriter(multipartBody)
+	s.Require().NoError(multipartWriter.WriteField("name", "goravel"))
+	s.Require().NoError(multipartWriter.WriteField("age", "18"))
+	s.Require().NoError(multipartWriter.Close())
+
+	protobufBody, err := proto.Marshal(&example.CreateUserRequest{UserId: 2, Name: "goravel", Age: 18})
+	s.Require().NoError(err)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        io.Reader
+		expectBody  string
+	}{
+		{
+			name:        "Happy path - empty body",
+			contentType: "application/json",
+			body:        strings.NewReader(""),
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2}}`,
+		},
+		{
+			name:        "Happy path - form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        strings.NewReader("name=goravel&age=18"),
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"goravel","age":18}}`,
+		},
+		{
+			name:        "Happy path - multipart form",
+			contentType: multipartWriter.FormDataContentType(),
+			body:        multipartBody,
+			expectBody:  `{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"goravel","age":18}}`,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%s/users", httpPort), test.body)
+			s.Require().NoError(err)
+
+			req.Header.Set("Content-Type", test.contentType)
+
+			resp, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			body, err := io.ReadAll(resp.Body)
+			s.Require().NoError(err)
 
+			s.Equal(http.StatusOK, resp.StatusCode)
+			s.Equal(test.expectBody, strings.ReplaceAll(string(body), " ", ""))
+		})
+	}
+
+	s.Run("Happy path - protobuf", func() {
+		s.inject = func(ctx contractshttp.Context) {
+			Inject(ctx, "user_id", 2, Overridable())
+		}
+
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%s/users", httpPort), bytes.NewReader(protobufBody))
+		s.Require().NoError(err)
+
+		req.Header.Set("Content-Type", "application/x-protobuf")
+
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		body, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		var createUserResponse example.CreateUserResponse
+		s.Require().NoError(proto.Unmarshal(body, &createUserResponse))
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal("goravel", createUserResponse.GetUser().GetName())
+		s.Equal(int32(18), createUserResponse.GetUser().GetAge())
+	})
+}
+
+func (s *ControllerTestSuite) TestPut() {
 	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("http://127.0.0.1:%s/users/1?age=18", httpPort), strings.NewReader(`{
 		"name": "goravel"
 	}`))
@@ -233,13 +963,9 @@ func (s *ControllerTestSuite) TestPut() {
 	}
 
 	s.Equal(`{"status":{"code":200},"user":{"id":1,"user_id":2,"name":"goravel","age":18}}`, strings.ReplaceAll(string(body), " ", ""))
-
-	mockConfig.AssertExpectations(s.T())
 }
 
 func (s *ControllerTestSuite) TestDelete() {
-	mockConfig := mockConfig()
-
 	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://127.0.0.1:%s/users/1?age=18", httpPort), nil)
 	s.Require().NoError(err)
 
@@ -257,24 +983,27 @@ func (s *ControllerTestSuite) TestDelete() {
 	}
 
 	s.Equal(`{"status":{"code":200}}`, strings.ReplaceAll(string(body), " ", ""))
-
-	mockConfig.AssertExpectations(s.T())
-}
-
-func mockConfig() *mocksconfig.Config {
-	mockConfig := mockFactory.Config()
-	mockConfig.EXPECT().Get("gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
-		return ctx.Response().Success().String("fallback")
-	}).Once()
-	mockConfig.EXPECT().GetString("gateway.host").Return(gatewayHost).Once()
-	mockConfig.EXPECT().GetString("gateway.port").Return(gatewayPort).Once()
-	FacadesConfig = mockConfig
-
-	return mockConfig
 }
 
 type UserController struct {
 	example.UnimplementedUserServiceServer
+	// failures is the number of the next GetUser and CreateUser calls that return Unavailable.
+	failures atomic.Int32
+	// getUsersCalls is the number of the GetUsers calls.
+	getUsersCalls atomic.Int32
+}
+
+// unavailable consumes a failure.
+func (r *UserController) unavailable() error {
+	for {
+		failures := r.failures.Load()
+		if failures <= 0 {
+			return nil
+		}
+		if r.failures.CompareAndSwap(failures, failures-1) {
+			return status.Error(codes.Unavailable, "user service is restarting")
+		}
+	}
 }
 
 func NewUserController() *UserController {
@@ -282,6 +1011,13 @@ func NewUserController() *UserController {
 }
 
 func (r *UserController) GetUser(ctx context.Context, req *example.GetUserRequest) (*example.GetUserResponse, error) {
+	if err := r.unavailable(); err != nil {
+		return nil, err
+	}
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
 	var name string
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		if n, ok := md["name"]; ok {
@@ -289,9 +1025,18 @@ func (r *UserController) GetUser(ctx context.Context, req *example.GetUserReques
 		}
 	}
 
-	if err := grpc.SendHeader(ctx, metadata.New(map[string]string{
+	header := metadata.New(map[string]string{
 		"custom-header": "goravel",
-	})); err != nil {
+	})
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		header.Set("tags", md.Get("tag")...)
+		header.Set("hop", md.Get("hop")...)
+		header.Set("request-id", md.Get("x-request-id")...)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		header.Set("deadline", "true")
+	}
+	if err := grpc.SendHeader(ctx, header); err != nil {
 		return nil, err
 	}
 
@@ -309,9 +1054,16 @@ func (r *UserController) GetUser(ctx context.Context, req *example.GetUserReques
 }
 
 func (r *UserController) GetUsers(ctx context.Context, req *example.GetUsersRequest) (*example.GetUsersResponse, error) {
-	if err := grpc.SendHeader(ctx, metadata.New(map[string]string{
+	r.getUsersCalls.Add(1)
+
+	header := metadata.New(map[string]string{
 		"custom-header": "goravel",
-	})); err != nil {
+	})
+	// The client decides the Cache-Control of the response.
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		header.Set("cache-control", md.Get("cache-control")...)
+	}
+	if err := grpc.SendHeader(ctx, header); err != nil {
 		return nil, err
 	}
 
@@ -320,24 +1072,34 @@ func (r *UserController) GetUsers(ctx context.Context, req *example.GetUsersRequ
 			Code: 200,
 		},
 		User: &example.User{
-			Id:     1,
-			UserId: req.GetUserId(),
-			Name:   req.GetName(),
-			Age:    req.GetAge(),
+			Id:      1,
+			UserId:  req.GetUserId(),
+			Name:    req.GetName(),
+			Age:     req.GetAge(),
+			RoleIds: req.GetRoleIds(),
+			Tenant:  req.GetTenant(),
+			Admin:   req.GetAdmin(),
 		},
 	}, nil
 }
 
 func (r *UserController) CreateUser(ctx context.Context, req *example.CreateUserRequest) (*example.CreateUserResponse, error) {
+	if err := r.unavailable(); err != nil {
+		return nil, err
+	}
+
 	return &example.CreateUserResponse{
 		Status: &example.Status{
 			Code: 200,
 		},
 		User: &example.User{
-			Id:     1,
-			UserId: req.GetUserId(),
-			Name:   req.GetName(),
-			Age:    req.GetAge(),
+			Id:      1,
+			UserId:  req.GetUserId(),
+			Name:    req.GetName(),
+			Age:     req.GetAge(),
+			RoleIds: req.GetRoleIds(),
+			Tenant:  req.GetTenant(),
+			Admin:   req.GetAdmin(),
 		},
 	}, nil
 }
@@ -364,6 +1126,25 @@ func (r *UserController) DeleteUser(ctx context.Context, req *example.DeleteUser
 	}, nil
 }
 
+func (r *UserController) WatchUsers(req *example.WatchUsersRequest, stream example.UserService_WatchUsersServer) error {
+	if req.GetCount() <= 0 {
+		return status.Error(codes.InvalidArgument, "count must be positive")
+	}
+
+	for i := range req.GetCount() {
+		if err := stream.Send(&example.WatchUsersResponse{
+			User: &example.User{
+				Id:     int32(i + 1),
+				UserId: req.GetUserId(),
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type DataResponse struct {
 	code        int
 	contentType string
@@ -378,6 +1159,10 @@ func (r *DataResponse) Abort() error {
 func (r *DataResponse) Render() error {
 	r.writer.WriteHeader(r.code)
 	r.writer.Header().Set("Content-Type", r.contentType)
+	// Like gin, the body isn't written if the status doesn't allow it.
+	if r.code == http.StatusNotModified {
+		return nil
+	}
 	if _, err := r.writer.Write(r.data); err != nil {
 		return err
 	}
@@ -385,6 +1170,34 @@ func (r *DataResponse) Render() error {
 	return nil
 }
 
+type StreamResponse struct {
+	code   int
+	step   func(contractshttp.StreamWriter) error
+	writer http.ResponseWriter
+}
+
+func (r *StreamResponse) Render() error {
+	r.writer.WriteHeader(r.code)
+
+	return r.step(&StreamWriter{r.writer})
+}
+
+type StreamWriter struct {
+	writer http.ResponseWriter
+}
+
+func (r *StreamWriter) Write(data []byte) (int, error) {
+	return r.writer.Write(data)
+}
+
+func (r *StreamWriter) WriteString(data string) (int, error) {
+	return r.writer.Write([]byte(data))
+}
+
+func (r *StreamWriter) Flush() error {
+	return http.NewResponseController(r.writer).Flush()
+}
+
 type TestContext struct {
 	ctx     context.Context
 	request *http.Request
@@ -705,8 +1518,8 @@ func (r *TestResponse) Status(int) contractshttp.ResponseStatus {
 	panic("do not need to implement it")
 }
 
-func (r *TestResponse) Stream(int, func(contractshttp.StreamWriter) error) contractshttp.Response {
-	panic("do not need to implement it")
+func (r *TestResponse) Stream(code int, step func(contractshttp.StreamWriter) error) contractshttp.Response {
+	return &StreamResponse{code, step, r.ctx.writer}
 }
 
 func (r *TestResponse) WithoutCookie(string) contractshttp.ContextResponse {
@@ -714,7 +1527,7 @@ func (r *TestResponse) WithoutCookie(string) contractshttp.ContextResponse {
 }
 
 func (r *TestResponse) Writer() http.ResponseWriter {
-	panic("do not need to implement it")
+	return r.ctx.writer
 }
 
 func (r *TestResponse) Flush() {
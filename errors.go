@@ -0,0 +1,157 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/goravel/framework/foundation/json"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrInjectProtobuf is returned if values are injected to a request with a protobuf body.
+var ErrInjectProtobuf = errors.New("injected values can't be merged into a protobuf body, use InjectMetadata or Overridable instead")
+
+// ErrInjectJSONArray is returned if values are injected to a request with a JSON array body.
+var ErrInjectJSONArray = errors.New("injected values can't be merged into a JSON array body, use InjectMetadata or Overridable instead")
+
+// ErrCircuitOpen is matched by errors.Is if the request is rejected because the circuit breaker of the gRPC backend is
+// open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is wrapped by the *StatusError passed to the fallback if the circuit breaker of the gRPC backend is
+// open, the request isn't sent to the backend in that case.
+type CircuitOpenError struct {
+	Backend string
+}
+
+func (r *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker of gRPC %s backend is open", r.Backend)
+}
+
+func (r *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// StatusError is passed to the fallback when the gateway answers with a non-2xx status.
+type StatusError struct {
+	// StatusCode is the HTTP status that should be returned to the client, after applying gateway.status_codes.
+	StatusCode int
+	// OriginalStatusCode is the HTTP status answered by the gateway.
+	OriginalStatusCode int
+	// Code is the gRPC code returned by the backend, codes.Unknown if the body is not a gRPC status.
+	Code    codes.Code
+	Message string
+	// Body is the raw response body of the gateway.
+	Body []byte
+
+	// cause is the *CircuitOpenError if the circuit breaker of the backend is open.
+	cause error
+}
+
+func (r *StatusError) Error() string {
+	if r.Message == "" {
+		return fmt.Sprintf("gateway responded with status %d", r.OriginalStatusCode)
+	}
+
+	return fmt.Sprintf("gateway responded with status %d: %s", r.OriginalStatusCode, r.Message)
+}
+
+// HTTPStatusFromError returns the HTTP status that should be returned to the client for the error.
+func HTTPStatusFromError(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	if errors.Is(err, ErrInjectProtobuf) {
+		return http.StatusUnsupportedMediaType
+	}
+	if errors.Is(err, ErrInjectJSONArray) {
+		return http.StatusBadRequest
+	}
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusInternalServerError
+}
+
+func (r *StatusError) Unwrap() error {
+	return r.cause
+}
+
+// newStatusError parses the error returned by the Gateway, statusCodes is gateway.status_codes, the status of the Gateway
+// is used if the code is not configured.
+func newStatusError(statusCode int, body []byte, statusCodes map[codes.Code]int) *StatusError {
+	statusErr := &StatusError{
+		StatusCode:         statusCode,
+		OriginalStatusCode: statusCode,
+		Code:               codes.Unknown,
+		Body:               body,
+	}
+
+	if code, message, ok := grpcStatusFromBody(body); ok {
+		statusErr.Code = code
+		statusErr.Message = message
+		if configured, exist := statusCodes[statusErr.Code]; exist {
+			statusErr.StatusCode = configured
+		}
+	}
+	if backend, ok := circuitOpenFromBody(body); ok {
+		statusErr.cause = &CircuitOpenError{Backend: backend}
+	}
+
+	return statusErr
+}
+
+// grpcStatusFromBody parses the body answered by the gateway for a gRPC error, it's a google.rpc.Status message, or a
+// google.rpc.Status message in the error field if a stream fails before its first message.
+func grpcStatusFromBody(body []byte) (codes.Code, string, bool) {
+	type grpcStatus struct {
+		Code    *int   `json:"code"`
+		Message string `json:"message"`
+	}
+	var status struct {
+		grpcStatus
+		Error *grpcStatus `json:"error"`
+	}
+	if err := json.New().Unmarshal(body, &status); err != nil {
+		return codes.Unknown, "", false
+	}
+	if status.Code == nil && status.Error != nil {
+		status.grpcStatus = *status.Error
+	}
+	if status.Code == nil {
+		return codes.Unknown, "", false
+	}
+
+	return codes.Code(*status.Code), status.Message, true
+}
+
+// circuitOpenFromBody returns the backend if the body is the error answered by an open circuit breaker, the
+// google.rpc.Status message contains a google.rpc.ErrorInfo detail in that case.
+func circuitOpenFromBody(body []byte) (string, bool) {
+	var grpcStatus struct {
+		Details []struct {
+			Reason   string            `json:"reason"`
+			Domain   string            `json:"domain"`
+			Metadata map[string]string `json:"metadata"`
+		} `json:"details"`
+	}
+	if err := json.New().Unmarshal(body, &grpcStatus); err != nil {
+		return "", false
+	}
+
+	for _, detail := range grpcStatus.Details {
+		if detail.Reason == circuitOpenReason && detail.Domain == errorDomain {
+			return detail.Metadata["backend"], true
+		}
+	}
+
+	return "", false
+}
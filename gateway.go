@@ -5,20 +5,35 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 
 	"github.com/gookit/color"
 	"github.com/goravel/framework/contracts/config"
 	contractsgrpc "github.com/goravel/framework/contracts/grpc"
-	contractshttp "github.com/goravel/framework/contracts/http"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
-	"google.golang.org/grpc"
+
+	"github.com/goravel/gateway/contracts"
 )
 
-type Handler func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+type Handler = contracts.Handler
+
+// instance is the Gateway running in the current process, the controller dispatches requests to its ServeMux
+// directly instead of sending them to gateway.host and gateway.port.
+var instance atomic.Pointer[Gateway]
 
 type Gateway struct {
 	config config.Config
 	grpc   contractsgrpc.Grpc
+	mux    *runtime.ServeMux
+
+	mu          sync.Mutex
+	server      *http.Server
+	connections map[string]*backend
+	cancelWatch context.CancelFunc
+	components  *components
+	inflight    sync.WaitGroup
+	registered  []Grpc
 }
 
 func NewGateway(config config.Config, grpc contractsgrpc.Grpc) *Gateway {
@@ -28,64 +43,279 @@ func NewGateway(config config.Config, grpc contractsgrpc.Grpc) *Gateway {
 	}
 }
 
+// Run registers the gRPC handlers and serves the Gateway on gateway.host and gateway.port. If both of them are empty,
+// the Gateway only serves the requests of the controller in-process.
 func (r *Gateway) Run(serveMux ...*runtime.ServeMux) error {
-	host := r.config.GetString("gateway.host")
-	port := r.config.GetString("gateway.port")
-	if host == "" || port == "" {
-		return errors.New("please initialize GATEWAY_HOST and GATEWAY_PORT")
+	r.mu.Lock()
+	registered := r.registered
+	r.mu.Unlock()
+
+	gatewayConfig, err := parseConfig(r.config, registered)
+	if err != nil {
+		return err
 	}
 
-	mux := runtime.NewServeMux()
+	mux := runtime.NewServeMux(runtime.WithMarshalerOption(MIMEProtobuf, &runtime.ProtoMarshaller{}))
 	if len(serveMux) > 0 {
 		mux = serveMux[0]
 	}
+	for _, option := range serveMuxOptions() {
+		option(mux)
+	}
+
+	// The middlewares are applied when a handler is registered, the health and metrics endpoints are registered before
+	// the middlewares below, so the probes and the scrapes aren't traced, logged, counted or rate limited.
+	if err := r.registerHealth(mux, gatewayConfig.health); err != nil {
+		return fmt.Errorf("register health endpoints failed: %v", err)
+	}
+
+	components := newComponents(gatewayConfig)
+	var metrics *metrics
+	if gatewayConfig.metricsPath != "" {
+		metrics = newMetrics()
+		if components.coalescer != nil {
+			metrics.registry.MustRegister(components.coalescer.requests)
+		}
+		if err := metrics.register(mux, gatewayConfig.metricsPath); err != nil {
+			return fmt.Errorf("register metrics endpoint failed: %v", err)
+		}
+	}
+
+	components.tracing.register(mux)
+
+	var observers []func(info *requestInfo)
+	if components.accessLog != nil {
+		observers = append(observers, components.accessLog.observe)
+	}
+	if metrics != nil {
+		observers = append(observers, metrics.observe)
+	}
 
-	connections := make(map[string]*grpc.ClientConn)
-	clients := r.config.Get("grpc.servers").(map[string]any)
-	for name, params := range clients {
-		if name == "" {
-			return errors.New("gRPC client name is required")
+	registerRequestInfo(mux, observers...)
+	if metrics != nil {
+		runtime.WithMetadata(metrics.annotate)(mux)
+	}
+
+	if components.rateLimiter != nil {
+		runtime.WithMiddlewares(components.rateLimiter.middleware(mux))(mux)
+	}
+
+	// The middleware is applied when a handler is registered, so the routes registered below are bound to the
+	// backend that is being registered.
+	var registering *backend
+	runtime.WithMiddlewares(func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		if registering == nil {
+			return next
 		}
 
+		return registering.middleware(mux, next)
+	})(mux)
+
+	var backends []*backend
+	connections := make(map[string]*backend)
+	for _, grpcHandler := range gatewayConfig.handlers {
+		name := grpcHandler.Name
 		if _, exist := connections[name]; !exist {
 			connection, err := r.grpc.Client(context.Background(), name)
 			if err != nil {
+				closeBackends(backends)
 				return fmt.Errorf("init gRPC %s client failed: %v", name, err)
 			}
 
-			connections[name] = connection
+			connections[name] = newBackend(name, connection)
+			connections[name].breaker = newCircuitBreaker(name, gatewayConfig.circuitBreaker)
+			backends = append(backends, connections[name])
 		}
 
-		handlers, exist := params.(map[string]any)["handlers"]
-		if !exist {
-			return fmt.Errorf("gRPC %s handlers is required", name)
+		registering = connections[name]
+		err := grpcHandler.Handler(context.Background(), mux, connections[name].conn)
+		registering = nil
+		if err != nil {
+			closeBackends(backends)
+			return fmt.Errorf("register gRPC %s handler failed: %v", name, err)
 		}
+	}
+
+	if gatewayConfig.startup == StartupFailFast {
+		ctx, cancel := context.WithTimeout(context.Background(), gatewayConfig.startupTimeout)
+		defer cancel()
 
-		for _, handler := range handlers.([]Handler) {
-			if err := handler(context.Background(), mux, connections[name]); err != nil {
-				return fmt.Errorf("register gRPC %s handler failed: %v", name, err)
+		for _, backend := range backends {
+			if err := backend.waitForReady(ctx); err != nil {
+				closeBackends(backends)
+				return err
 			}
 		}
 	}
 
-	addr := fmt.Sprintf("%s:%s", host, port)
+	watchCtx, cancelWatch := context.WithCancel(context.Background())
+	for _, backend := range backends {
+		go backend.watch(watchCtx)
+	}
+
+	r.mu.Lock()
+	r.mux = mux
+	r.connections = connections
+	r.cancelWatch = cancelWatch
+	r.components = components
+	r.mu.Unlock()
+	instance.Store(r)
+
+	if gatewayConfig.host == "" && gatewayConfig.port == "" {
+		return nil
+	}
+
+	addr := fmt.Sprintf("%s:%s", gatewayConfig.host, gatewayConfig.port)
 	server := &http.Server{
 		Addr:    addr,
-		Handler: mux,
+		Handler: r,
 	}
 
+	tlsOptions := gatewayConfig.tls
+	if tlsOptions.enabled() {
+		tlsConfig, err := tlsOptions.serverConfig()
+		if err != nil {
+			return err
+		}
+
+		server.TLSConfig = tlsConfig
+	}
+
+	r.mu.Lock()
+	r.server = server
+	r.mu.Unlock()
+
 	color.Greenln("[Gateway] Listening and serving Gateway on " + addr)
-	if err := server.ListenAndServe(); err != nil {
+	if err := r.listen(server, tlsOptions); err != nil {
+		if errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+
 		return fmt.Errorf("HTTP listen failed: %v", err)
 	}
 
 	return nil
 }
 
-func Inject[V NumberOrString](ctx contractshttp.Context, key string, value V) {
-	if injectValue, exist := ctx.Value(InjectKey).(map[string]any); exist {
-		injectValue[key] = value
-	} else {
-		ctx.WithValue(InjectKey, map[string]any{key: value})
+// Register adds handlers of the gRPC client name, they are registered with the handlers of grpc.servers when Run is
+// called. It can be called from service providers, modules don't need to edit the grpc config.
+func (r *Gateway) Register(name string, handlers ...Handler) error {
+	if name == "" {
+		return errors.New("gRPC client name is required")
+	}
+	if len(handlers) == 0 {
+		return fmt.Errorf("gRPC %s handlers is required", name)
+	}
+	for _, handler := range handlers {
+		if handler == nil {
+			return fmt.Errorf("gRPC %s handler can't be nil", name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, handler := range handlers {
+		r.registered = append(r.registered, Grpc{Name: name, Handler: handler})
+	}
+
+	return nil
+}
+
+// Validate parses the gateway and grpc.servers configuration and returns all problems of it, each problem is a
+// *ConfigError that contains the config path.
+func (r *Gateway) Validate() error {
+	r.mu.Lock()
+	registered := r.registered
+	r.mu.Unlock()
+
+	_, err := parseConfig(r.config, registered)
+
+	return err
+}
+
+// serveMuxOptions are applied to the ServeMux before registering handlers, the features of the controller rely on
+// them. They only add middlewares, so the marshalers and the header matcher of a ServeMux passed to Run are kept.
+func serveMuxOptions() []runtime.ServeMuxOption {
+	return []runtime.ServeMuxOption{
+		runtime.WithMiddlewares(requestIDMiddleware, streamMiddleware),
+	}
+}
+
+func (r *Gateway) listen(server *http.Server, tlsOptions tlsOptions) error {
+	if tlsOptions.enabled() {
+		return server.ListenAndServeTLS(tlsOptions.certFile, tlsOptions.keyFile)
+	}
+
+	return server.ListenAndServe()
+}
+
+func (r *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.inflight.Add(1)
+	defer r.inflight.Done()
+
+	r.mux.ServeHTTP(w, req)
+}
+
+// Shutdown stops accepting requests, waits for the in-flight requests to finish and closes the gRPC connections.
+// Run returns http.ErrServerClosed after Shutdown is called.
+func (r *Gateway) Shutdown(ctx context.Context) error {
+	instance.CompareAndSwap(r, nil)
+
+	r.mu.Lock()
+	server := r.server
+	connections := r.connections
+	cancelWatch := r.cancelWatch
+	components := r.components
+	r.server = nil
+	r.connections = nil
+	r.cancelWatch = nil
+	r.components = nil
+	r.mu.Unlock()
+
+	if cancelWatch != nil {
+		cancelWatch()
+	}
+
+	var errs []error
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown HTTP server failed: %v", err))
+		}
+	}
+
+	// The requests dispatched by the controller in-process don't go through the server.
+	drained := make(chan struct{})
+	go func() {
+		r.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("wait for in-flight requests failed: %v", ctx.Err()))
+	}
+
+	for name, backend := range connections {
+		if err := backend.conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close gRPC %s client failed: %v", name, err))
+		}
+	}
+
+	if components != nil {
+		if err := components.tracing.shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown tracing failed: %v", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// closeBackends closes the connections opened by Run if it fails before the Gateway is running, Shutdown closes them
+// otherwise.
+func closeBackends(backends []*backend) {
+	for _, backend := range backends {
+		_ = backend.conn.Close()
 	}
 }
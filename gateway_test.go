@@ -4,14 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	contractshttp "github.com/goravel/framework/contracts/http"
 	configmocks "github.com/goravel/framework/mocks/config"
 	grpcmocks "github.com/goravel/framework/mocks/grpc"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/goravel/gateway/proto/example"
 )
 
 func TestRun(t *testing.T) {
@@ -25,6 +33,7 @@ func TestRun(t *testing.T) {
 		mockConfig = new(configmocks.Config)
 		mockGrpc = new(grpcmocks.Grpc)
 		gateway = NewGateway(mockConfig, mockGrpc)
+		mockRunConfig(mockConfig)
 	}
 
 	tests := []struct {
@@ -37,6 +46,7 @@ func TestRun(t *testing.T) {
 			setup: func() {
 				mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
 				mockConfig.On("GetString", "gateway.port").Return("4001")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
 				mockConfig.On("Get", "grpc.servers").Return(map[string]any{
 					"goravel": map[string]any{
 						"handlers": []Handler{
@@ -46,7 +56,7 @@ func TestRun(t *testing.T) {
 						},
 					},
 				})
-				mockGrpc.On("Client", context.Background(), "goravel").Return(&grpc.ClientConn{}, nil)
+				mockGrpc.On("Client", context.Background(), "goravel").Return(newTestConnection(t, "127.0.0.1:4006"), nil)
 			},
 		},
 		{
@@ -54,6 +64,16 @@ func TestRun(t *testing.T) {
 			setup: func() {
 				mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
 				mockConfig.On("GetString", "gateway.port").Return("4002")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+				mockConfig.On("Get", "grpc.servers").Return(map[string]any{})
+			},
+		},
+		{
+			name: "Happy path when gateway.host and gateway.port are empty",
+			setup: func() {
+				mockConfig.On("GetString", "gateway.host").Return("")
+				mockConfig.On("GetString", "gateway.port").Return("")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
 				mockConfig.On("Get", "grpc.servers").Return(map[string]any{})
 			},
 		},
@@ -62,34 +82,63 @@ func TestRun(t *testing.T) {
 			setup: func() {
 				mockConfig.On("GetString", "gateway.host").Return("")
 				mockConfig.On("GetString", "gateway.port").Return("4001")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+				mockConfig.On("Get", "grpc.servers").Return(map[string]any{})
 			},
-			expectErr: errors.New("please initialize GATEWAY_HOST and GATEWAY_PORT"),
+			expectErr: errors.New("gateway.host: please initialize GATEWAY_HOST, it's required if gateway.port is set"),
 		},
 		{
 			name: "error, gateway.port is empty",
 			setup: func() {
 				mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
 				mockConfig.On("GetString", "gateway.port").Return("")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+				mockConfig.On("Get", "grpc.servers").Return(map[string]any{})
 			},
-			expectErr: errors.New("please initialize GATEWAY_HOST and GATEWAY_PORT"),
+			expectErr: errors.New("gateway.port: please initialize GATEWAY_PORT, it's required if gateway.host is set"),
 		},
 		{
 			name: "error, grpc handler is nil",
 			setup: func() {
 				mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
 				mockConfig.On("GetString", "gateway.port").Return("4001")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
 				mockConfig.On("Get", "grpc.servers").Return(map[string]any{
 					"goravel": map[string]any{},
 				})
-				mockGrpc.On("Client", context.Background(), "goravel").Return(&grpc.ClientConn{}, nil)
 			},
-			expectErr: fmt.Errorf("gRPC %s handlers is required", "goravel"),
+			expectErr: errors.New("grpc.servers.goravel.handlers: is required"),
+		},
+		{
+			name: "error, grpc.servers is invalid",
+			setup: func() {
+				mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
+				mockConfig.On("GetString", "gateway.port").Return("4001")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+				mockConfig.On("Get", "grpc.servers").Return([]string{"goravel"})
+			},
+			expectErr: errors.New("grpc.servers: should be map[string]any, got []string"),
+		},
+		{
+			name: "error, grpc handlers are invalid",
+			setup: func() {
+				mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
+				mockConfig.On("GetString", "gateway.port").Return("4001")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+				mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+					"goravel": map[string]any{
+						"handlers": []any{"handler"},
+					},
+				})
+			},
+			expectErr: errors.New("grpc.servers.goravel.handlers: should be []gateway.Handler, got []interface {}"),
 		},
 		{
 			name: "error, grpc handler returns error",
 			setup: func() {
 				mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
 				mockConfig.On("GetString", "gateway.port").Return("4001")
+				mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
 				mockConfig.On("Get", "grpc.servers").Return(map[string]any{
 					"goravel": map[string]any{
 						"handlers": []Handler{
@@ -99,7 +148,7 @@ func TestRun(t *testing.T) {
 						},
 					},
 				})
-				mockGrpc.On("Client", context.Background(), "goravel").Return(&grpc.ClientConn{}, nil)
+				mockGrpc.On("Client", context.Background(), "goravel").Return(newTestConnection(t, "127.0.0.1:4006"), nil)
 			},
 			expectErr: fmt.Errorf("register gRPC %s handler failed: %v", "goravel", errors.New("error")),
 		},
@@ -125,3 +174,191 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestShutdown(t *testing.T) {
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+	mockRunConfig(mockConfig)
+
+	connection, err := grpc.NewClient("127.0.0.1:4004", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+
+	mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
+	mockConfig.On("GetString", "gateway.port").Return("4003")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"goravel": map[string]any{
+			"handlers": []Handler{
+				func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+					return nil
+				},
+			},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "goravel").Return(connection, nil)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- gateway.Run()
+	}()
+	time.Sleep(1 * time.Second)
+
+	assert.Nil(t, gateway.Shutdown(context.Background()))
+	assert.ErrorIs(t, <-errChan, http.ErrServerClosed)
+	assert.Equal(t, connectivity.Shutdown, connection.GetState())
+	assert.Nil(t, instance.Load())
+
+	mockConfig.AssertExpectations(t)
+	mockGrpc.AssertExpectations(t)
+}
+
+func TestRegister(t *testing.T) {
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	var registered []string
+	handler := func(name string) Handler {
+		return func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+			registered = append(registered, name)
+			return nil
+		}
+	}
+
+	assert.EqualError(t, gateway.Register("", handler("user")), "gRPC client name is required")
+	assert.EqualError(t, gateway.Register("goravel"), "gRPC goravel handlers is required")
+	assert.EqualError(t, gateway.Register("goravel", handler("user"), nil), "gRPC goravel handler can't be nil")
+
+	assert.Nil(t, gateway.Register("goravel", handler("user"), handler("role")))
+	assert.Nil(t, gateway.Register("module", handler("module")))
+
+	mockRunConfig(mockConfig)
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"goravel": map[string]any{
+			"handlers": []Handler{handler("config")},
+		},
+		// The handlers of module are added by Register only.
+		"module": map[string]any{},
+	})
+	for _, name := range []string{"goravel", "module"} {
+		connection, err := grpc.NewClient("127.0.0.1:4004", grpc.WithTransportCredentials(insecure.NewCredentials()))
+		assert.Nil(t, err)
+		mockGrpc.On("Client", context.Background(), name).Return(connection, nil).Once()
+	}
+
+	assert.Nil(t, gateway.Run())
+	assert.Equal(t, []string{"config", "user", "role", "module"}, registered)
+	assert.Nil(t, gateway.Shutdown(context.Background()))
+
+	mockConfig.AssertExpectations(t)
+	mockGrpc.AssertExpectations(t)
+}
+
+func TestUnavailableBackend(t *testing.T) {
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	mockRunConfig(mockConfig)
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	// Nothing listens on the port.
+	mockGrpc.On("Client", context.Background(), "example").Return(newTestConnection(t, "127.0.0.1:4006"), nil)
+
+	assert.Nil(t, gateway.Run())
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	// The first request dials the connection, the following requests answer 503 immediately once the connection fails.
+	assert.Eventually(t, func() bool {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+		return recorder.Code == http.StatusServiceUnavailable &&
+			strings.Contains(recorder.Body.String(), "gRPC example backend is unavailable")
+	}, 5*time.Second, 100*time.Millisecond)
+
+	mockConfig.AssertExpectations(t)
+	mockGrpc.AssertExpectations(t)
+}
+
+func TestRunFailFast(t *testing.T) {
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return nil
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return(StartupFailFast)
+	mockConfig.On("GetInt", "gateway.connection.timeout", 5).Return(1)
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(false)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	connection := newTestConnection(t, "127.0.0.1:4006")
+	mockGrpc.On("Client", context.Background(), "example").Return(connection, nil)
+
+	assert.ErrorContains(t, gateway.Run(), "gRPC example backend isn't ready")
+	assert.Equal(t, connectivity.Shutdown, connection.GetState())
+
+	mockConfig.AssertExpectations(t)
+	mockGrpc.AssertExpectations(t)
+}
+
+func newTestConnection(t *testing.T, target string) *grpc.ClientConn {
+	connection, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+
+	return connection
+}
+
+// mockRunConfig mocks the configuration that is read by Run besides gateway.host, gateway.port, gateway.tls and
+// grpc.servers.
+func mockRunConfig(mockConfig *configmocks.Config) {
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return nil
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(false)
+}
@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// defaultDenyHeaders are hop-by-hop headers and headers that are set by the transport, they are never proxied unless
+// they are set in gateway.headers.allow.
+var defaultDenyHeaders = []string{
+	"Connection",
+	"Content-Length",
+	"Host",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// headerFilter decides which headers are proxied between the client and the Gateway.
+type headerFilter struct {
+	allow map[string]struct{}
+	deny  map[string]struct{}
+}
+
+// copyHeaders copies all values of the proxied headers from src to dst.
+func (r *headerFilter) copyHeaders(dst, src http.Header) {
+	// Headers listed in Connection are hop-by-hop as well, see RFC 7230, section 6.1.
+	deny := make(map[string]struct{})
+	for _, value := range src.Values("Connection") {
+		for _, key := range strings.Split(value, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				deny[textproto.CanonicalMIMEHeaderKey(key)] = struct{}{}
+			}
+		}
+	}
+
+	for key, values := range src {
+		key = textproto.CanonicalMIMEHeaderKey(key)
+		if !r.allowed(key, deny) {
+			continue
+		}
+
+		for _, value := range values {
+			dst.Add(key, value)
+		}
+	}
+}
+
+func (r *headerFilter) allowed(key string, deny map[string]struct{}) bool {
+	if _, exist := r.allow[key]; exist {
+		return true
+	}
+	if _, exist := r.deny[key]; exist {
+		return false
+	}
+
+	_, exist := deny[key]
+
+	return !exist
+}
+
+func canonicalHeaderSet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[textproto.CanonicalMIMEHeaderKey(key)] = struct{}{}
+	}
+
+	return set
+}
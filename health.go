@@ -0,0 +1,162 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goravel/framework/foundation/json"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthOptions is the gateway.health configuration, an empty path disables the endpoint.
+type healthOptions struct {
+	healthz string
+	readyz  string
+	timeout time.Duration
+}
+
+// backendHealth is the readiness of a backend, Services contains the status of each service reported by the backend.
+type backendHealth struct {
+	Status   string            `json:"status"`
+	Services map[string]string `json:"services,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+func (r *Gateway) registerHealth(mux *runtime.ServeMux, options healthOptions) error {
+	if options.healthz != "" {
+		if err := mux.HandlePath(http.MethodGet, options.healthz, r.healthz); err != nil {
+			return err
+		}
+	}
+	if options.readyz != "" {
+		if err := mux.HandlePath(http.MethodGet, options.readyz, r.readyz(options.timeout)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// healthz reports that the Gateway is alive and the address it listens on, the address is empty if the Gateway
+// serves the controller in-process only.
+func (r *Gateway) healthz(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	r.mu.Lock()
+	server := r.server
+	r.mu.Unlock()
+
+	var listener string
+	if server != nil {
+		listener = server.Addr
+	}
+
+	writeHealth(w, http.StatusOK, map[string]any{
+		"status":   "ok",
+		"listener": listener,
+	})
+}
+
+// readyz queries every backend with the grpc.health.v1 protocol, it answers 503 if any backend isn't serving.
+func (r *Gateway) readyz(timeout time.Duration) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, _ map[string]string) {
+		r.mu.Lock()
+		backends := make([]*backend, 0, len(r.connections))
+		for _, backend := range r.connections {
+			backends = append(backends, backend)
+		}
+		r.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+
+		var (
+			mu      sync.Mutex
+			wg      sync.WaitGroup
+			healths = make(map[string]backendHealth, len(backends))
+		)
+		for _, backend := range backends {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				health := backend.health(ctx)
+				mu.Lock()
+				healths[backend.name] = health
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		statusCode := http.StatusOK
+		ready := "ready"
+		for _, health := range healths {
+			if health.Status != healthpb.HealthCheckResponse_SERVING.String() {
+				statusCode = http.StatusServiceUnavailable
+				ready = "unavailable"
+			}
+		}
+
+		writeHealth(w, statusCode, map[string]any{
+			"status":   ready,
+			"backends": healths,
+		})
+	}
+}
+
+// health lists the services of the backend, Check of the server is used if the backend doesn't implement List. A
+// backend that doesn't implement the health service at all is serving, since it answers.
+func (r *backend) health(ctx context.Context) backendHealth {
+	client := healthpb.NewHealthClient(r.conn)
+
+	services := make(map[string]string)
+	resp, err := client.List(ctx, &healthpb.HealthListRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		var checkResp *healthpb.HealthCheckResponse
+		checkResp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err == nil {
+			services[""] = checkResp.GetStatus().String()
+		}
+	} else if err == nil {
+		for service, serviceResp := range resp.GetStatuses() {
+			services[service] = serviceResp.GetStatus().String()
+		}
+	}
+
+	if status.Code(err) == codes.Unimplemented {
+		return backendHealth{Status: healthpb.HealthCheckResponse_SERVING.String()}
+	}
+	if err != nil {
+		return backendHealth{
+			Status: healthpb.HealthCheckResponse_UNKNOWN.String(),
+			Error:  status.Convert(err).Message(),
+		}
+	}
+
+	health := backendHealth{
+		Status:   healthpb.HealthCheckResponse_SERVING.String(),
+		Services: services,
+	}
+	for _, serviceStatus := range services {
+		if serviceStatus != healthpb.HealthCheckResponse_SERVING.String() {
+			health.Status = healthpb.HealthCheckResponse_NOT_SERVING.String()
+		}
+	}
+
+	return health
+}
+
+func writeHealth(w http.ResponseWriter, statusCode int, body map[string]any) {
+	data, err := json.New().Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", MIMEJSON)
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/foundation/json"
+	configmocks "github.com/goravel/framework/mocks/config"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealth(t *testing.T) {
+	healthy := health.NewServer()
+	healthy.SetServingStatus("example.UserService", healthpb.HealthCheckResponse_SERVING)
+	startHealthServer(t, "127.0.0.1:4007", healthy)
+
+	notServing := health.NewServer()
+	notServing.SetServingStatus("example.UserService", healthpb.HealthCheckResponse_NOT_SERVING)
+	startHealthServer(t, "127.0.0.1:4008", notServing)
+
+	handler := func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+		return nil
+	}
+
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return nil
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("/healthz")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("/readyz")
+	mockConfig.On("GetInt", "gateway.health.timeout", 1).Return(1)
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(true)
+	mockConfig.On("Get", "gateway.rate_limit.store").Return(nil)
+	mockConfig.On("Get", "gateway.rate_limit.limits").Return([]RateLimit{{Route: "*", Rate: 0.01, Burst: 1}})
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(false)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"healthy": map[string]any{
+			"handlers": []Handler{handler},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "healthy").Return(newTestConnection(t, "127.0.0.1:4007"), nil)
+
+	require.Nil(t, gateway.Run())
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	// The probes aren't rate limited.
+	for range 2 {
+		status, body := requestHealth(t, gateway, "/healthz")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, map[string]any{"status": "ok", "listener": ""}, body)
+	}
+
+	status, body := requestHealth(t, gateway, "/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{
+		"status": "ready",
+		"backends": map[string]any{
+			"healthy": map[string]any{
+				"status": "SERVING",
+				"services": map[string]any{
+					"":                    "SERVING",
+					"example.UserService": "SERVING",
+				},
+			},
+		},
+	}, body)
+
+	// A backend that isn't serving or can't be reached makes the Gateway unready.
+	gateway.mu.Lock()
+	gateway.connections["not_serving"] = newBackend("not_serving", newTestConnection(t, "127.0.0.1:4008"))
+	gateway.connections["down"] = newBackend("down", newTestConnection(t, "127.0.0.1:4006"))
+	gateway.mu.Unlock()
+
+	status, body = requestHealth(t, gateway, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "unavailable", body["status"])
+	backends := body["backends"].(map[string]any)
+	assert.Equal(t, "SERVING", backends["healthy"].(map[string]any)["status"])
+	assert.Equal(t, map[string]any{
+		"status": "NOT_SERVING",
+		"services": map[string]any{
+			"":                    "SERVING",
+			"example.UserService": "NOT_SERVING",
+		},
+	}, backends["not_serving"])
+	assert.Equal(t, "UNKNOWN", backends["down"].(map[string]any)["status"])
+	assert.NotEmpty(t, backends["down"].(map[string]any)["error"])
+
+	mockConfig.AssertExpectations(t)
+	mockGrpc.AssertExpectations(t)
+}
+
+func startHealthServer(t *testing.T, addr string, healthServer *health.Server) {
+	listener, err := net.Listen("tcp", addr)
+	require.Nil(t, err)
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+}
+
+func requestHealth(t *testing.T, gateway *Gateway, path string) (int, map[string]any) {
+	recorder := httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body map[string]any
+	require.Nil(t, json.New().Unmarshal(recorder.Body.Bytes(), &body))
+
+	return recorder.Code, body
+}
@@ -0,0 +1,408 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/spf13/cast"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// InjectOption configures an injected value.
+type InjectOption func(options *injectOptions)
+
+type injectOptions struct {
+	overridable bool
+}
+
+// Overridable lets the value supplied by the client win over the injected value, the injected value is only used if
+// the client doesn't supply the key. By default, the injected value always wins.
+func Overridable() InjectOption {
+	return func(options *injectOptions) {
+		options.overridable = true
+	}
+}
+
+// Inject injects a value to the gRPC request, it can be a number, a string, a bool, a slice, a map, a struct or a
+// proto.Message. The value is set to the body of POST, PUT and PATCH requests, and is encoded to queries of GET and
+// DELETE requests: slices become repeated queries and nested fields become dotted queries, for example, tenant.id.
+func Inject[V any](ctx contractshttp.Context, key string, value V, options ...InjectOption) {
+	inject(ctx, InjectKey, key, value, options)
+}
+
+// InjectMetadata injects a value to the gRPC metadata, backend services can read it via metadata.FromIncomingContext.
+// Slices become multiple values, maps, structs and proto.Message are encoded to JSON, proto.Message is encoded to
+// binary if the key ends with -bin.
+func InjectMetadata[V any](ctx contractshttp.Context, key string, value V, options ...InjectOption) {
+	inject(ctx, InjectMetadataKey, strings.ToLower(key), value, options)
+}
+
+func inject(ctx contractshttp.Context, ctxKey, key string, value any, options []InjectOption) {
+	if injectValue, exist := ctx.Value(ctxKey).(map[string]any); exist {
+		injectValue[key] = value
+	} else {
+		ctx.WithValue(ctxKey, map[string]any{key: value})
+	}
+
+	var injectOptions injectOptions
+	for _, option := range options {
+		option(&injectOptions)
+	}
+
+	overridableKey := ctxKey + injectOverridableSuffix
+	overridable, exist := ctx.Value(overridableKey).(map[string]bool)
+	if !exist {
+		overridable = make(map[string]bool)
+		ctx.WithValue(overridableKey, overridable)
+	}
+	overridable[key] = injectOptions.overridable
+}
+
+// injectOverridable returns the injected keys of ctxKey that can be overridden by the client.
+func injectOverridable(ctx contractshttp.Context, ctxKey string) map[string]bool {
+	overridable, _ := ctx.Value(ctxKey + injectOverridableSuffix).(map[string]bool)
+
+	return overridable
+}
+
+// injectedValues returns the injected values of ctxKey that are normalized to JSON values.
+func injectedValues(ctx contractshttp.Context, ctxKey string) (map[string]any, error) {
+	injectValue, exist := ctx.Value(ctxKey).(map[string]any)
+	if !exist {
+		return nil, nil
+	}
+
+	values := make(map[string]any, len(injectValue))
+	for key, value := range injectValue {
+		normalized, err := normalizeInjectValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("inject %s failed: %v", key, err)
+		}
+
+		values[key] = normalized
+	}
+
+	return values, nil
+}
+
+// injectedMetadataValues returns the values of the injected metadata that are sent to the backend, normalized to JSON
+// values. The values supplied by the client win for the overridable keys, the same as injectMetadata.
+func injectedMetadataValues(ctx contractshttp.Context) (map[string]any, error) {
+	values, err := injectedValues(ctx, InjectMetadataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	overridable := injectOverridable(ctx, InjectMetadataKey)
+	for key := range values {
+		headerKey := runtime.MetadataHeaderPrefix + key
+		if overridable[key] && ctx.Request().Header(headerKey) != "" {
+			values[key] = ctx.Request().Headers().Values(headerKey)
+		}
+	}
+
+	return values, nil
+}
+
+// normalizeInjectValue converts the value to a scalar, []any or map[string]any. proto.Message is encoded via protojson
+// with the proto field names, others are encoded via encoding/json.
+func normalizeInjectValue(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	switch v := value.(type) {
+	case proto.Message:
+		data, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(v)
+	default:
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return value, nil
+		}
+
+		data, err = json.Marshal(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep numbers as json.Number to avoid losing the precision of int64.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var normalized any
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+// injectQuery strips the values supplied by the client for the injected keys from query, then adds the injected
+// values to it if encode is true.
+func injectQuery(ctx contractshttp.Context, query url.Values, injected map[string]any, encode bool) error {
+	overridable := injectOverridable(ctx, InjectKey)
+	for key, value := range injected {
+		if overridable[key] && hasQuery(query, key) {
+			continue
+		}
+
+		stripQuery(query, key)
+		if !encode {
+			continue
+		}
+
+		if err := encodeInjectQuery(query, key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// injectBody sets the injected values to dataJson, the values supplied by the client for the injected keys are
+// replaced, including their aliases.
+func injectBody(ctx contractshttp.Context, dataJson map[string]any, injected map[string]any) {
+	overridable := injectOverridable(ctx, InjectKey)
+	for key, value := range injected {
+		segments := strings.Split(key, ".")
+		current := dataJson
+		for i, segment := range segments {
+			existing, exist := popAliases(current, segment)
+			if i == len(segments)-1 {
+				if exist && overridable[key] {
+					current[segment] = existing
+				} else {
+					current[segment] = value
+				}
+				break
+			}
+
+			child, ok := existing.(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+			}
+			current[segment] = child
+			current = child
+		}
+	}
+}
+
+// popAliases deletes the field and its aliases from data and returns the value supplied by the client.
+func popAliases(data map[string]any, field string) (any, bool) {
+	var (
+		value any
+		exist bool
+	)
+	for _, alias := range fieldAliases(field) {
+		if v, ok := data[alias]; ok {
+			value, exist = v, true
+			delete(data, alias)
+		}
+	}
+
+	return value, exist
+}
+
+// hasQuery reports whether the client supplies the key, its aliases or its nested fields in query.
+func hasQuery(query url.Values, key string) bool {
+	for _, alias := range keyAliases(key) {
+		for queryKey := range query {
+			if queryKey == alias || strings.HasPrefix(queryKey, alias+".") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// stripQuery deletes the key, its aliases and its nested fields from query.
+func stripQuery(query url.Values, key string) {
+	for _, alias := range keyAliases(key) {
+		for queryKey := range query {
+			if queryKey == alias || strings.HasPrefix(queryKey, alias+".") {
+				query.Del(queryKey)
+			}
+		}
+	}
+}
+
+// keyAliases returns the dotted key with every combination of the field aliases, the Gateway maps all of them to the
+// same field.
+func keyAliases(key string) []string {
+	aliases := []string{""}
+	for _, segment := range strings.Split(key, ".") {
+		var next []string
+		for _, prefix := range aliases {
+			for _, alias := range fieldAliases(segment) {
+				if prefix == "" {
+					next = append(next, alias)
+				} else {
+					next = append(next, prefix+"."+alias)
+				}
+			}
+		}
+		aliases = next
+	}
+
+	return aliases
+}
+
+// fieldAliases returns the field with its proto name and JSON name, for example, user_id and userId.
+func fieldAliases(field string) []string {
+	aliases := []string{field}
+	for _, alias := range []string{snakeCase(field), camelCase(field)} {
+		if !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
+
+// camelCase converts the field to the JSON name that protoc generates.
+func camelCase(field string) string {
+	var (
+		builder strings.Builder
+		upper   bool
+	)
+	for _, char := range field {
+		if char == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			char = unicode.ToUpper(char)
+			upper = false
+		}
+		builder.WriteRune(char)
+	}
+
+	return builder.String()
+}
+
+func snakeCase(field string) string {
+	var builder strings.Builder
+	for i, char := range field {
+		if unicode.IsUpper(char) {
+			if i > 0 {
+				builder.WriteRune('_')
+			}
+			char = unicode.ToLower(char)
+		}
+		builder.WriteRune(char)
+	}
+
+	return builder.String()
+}
+
+// encodeInjectQuery adds the normalized value to query, slices are added as repeated keys and maps are flattened to
+// dotted keys, which is the format that the Gateway parses query parameters.
+func encodeInjectQuery(query url.Values, key string, value any) error {
+	switch v := value.(type) {
+	case nil:
+	case map[string]any:
+		for field, fieldValue := range v {
+			if err := encodeInjectQuery(query, key+"."+field, fieldValue); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				return fmt.Errorf("%s is a list of objects or lists that can't be encoded to queries", key)
+			}
+
+			query.Add(key, cast.ToString(item))
+		}
+	default:
+		query.Add(key, cast.ToString(v))
+	}
+
+	return nil
+}
+
+// injectMetadata sets the injected metadata to the Grpc-Metadata-* headers that the Gateway converts to gRPC
+// metadata, the headers supplied by the client are replaced unless the key is overridable.
+func injectMetadata(ctx contractshttp.Context, header http.Header) error {
+	injectValue, _ := ctx.Value(InjectMetadataKey).(map[string]any)
+	overridable := injectOverridable(ctx, InjectMetadataKey)
+	for key, value := range injectValue {
+		headerKey := runtime.MetadataHeaderPrefix + key
+		if overridable[key] && header.Get(headerKey) != "" {
+			continue
+		}
+
+		values, err := encodeInjectMetadata(key, value)
+		if err != nil {
+			return fmt.Errorf("inject metadata %s failed: %v", key, err)
+		}
+
+		header.Del(headerKey)
+		for _, value := range values {
+			header.Add(headerKey, value)
+		}
+	}
+
+	return nil
+}
+
+func encodeInjectMetadata(key string, value any) ([]string, error) {
+	// The Gateway decodes the values of binary keys from base64.
+	if message, ok := value.(proto.Message); ok && strings.HasSuffix(key, "-bin") {
+		data, err := proto.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{base64.StdEncoding.EncodeToString(data)}, nil
+	}
+
+	normalized, err := normalizeInjectValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := normalized.([]any)
+	if !ok {
+		items = []any{normalized}
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case nil:
+		case map[string]any, []any:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, string(data))
+		default:
+			values = append(values, cast.ToString(v))
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/goravel/gateway/proto/example"
+)
+
+func TestEncodeInjectQuery(t *testing.T) {
+	type Tenant struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name        string
+		value       any
+		expectQuery url.Values
+		expectErr   string
+	}{
+		{
+			name:        "Happy path - number",
+			value:       int64(9007199254740993),
+			expectQuery: url.Values{"key": {"9007199254740993"}},
+		},
+		{
+			name:        "Happy path - bool",
+			value:       true,
+			expectQuery: url.Values{"key": {"true"}},
+		},
+		{
+			name:        "Happy path - slice",
+			value:       []int64{1, 9007199254740993},
+			expectQuery: url.Values{"key": {"1", "9007199254740993"}},
+		},
+		{
+			name:        "Happy path - struct",
+			value:       Tenant{ID: 1, Name: "goravel"},
+			expectQuery: url.Values{"key.id": {"1"}, "key.name": {"goravel"}},
+		},
+		{
+			name:        "Happy path - map",
+			value:       map[string]any{"tenant": map[string]any{"id": 1}},
+			expectQuery: url.Values{"key.tenant.id": {"1"}},
+		},
+		{
+			name:        "Happy path - proto.Message",
+			value:       &example.User{UserId: 1, RoleIds: []int32{1, 2}},
+			expectQuery: url.Values{"key.user_id": {"1"}, "key.role_ids": {"1", "2"}},
+		},
+		{
+			name:      "error, list of objects",
+			value:     []Tenant{{ID: 1}},
+			expectErr: "key is a list of objects or lists that can't be encoded to queries",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := normalizeInjectValue(test.value)
+			assert.Nil(t, err)
+
+			query := url.Values{}
+			err = encodeInjectQuery(query, "key", value)
+			if test.expectErr != "" {
+				assert.EqualError(t, err, test.expectErr)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectQuery, query)
+		})
+	}
+}
+
+func TestNormalizeInjectValue(t *testing.T) {
+	value, err := normalizeInjectValue(&example.Tenant{Id: 1, Name: "goravel"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]any{"id": json.Number("1"), "name": "goravel"}, value)
+
+	value, err = normalizeInjectValue([]string{"goravel"})
+	assert.Nil(t, err)
+	assert.Equal(t, []any{"goravel"}, value)
+
+	value, err = normalizeInjectValue(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+}
+
+func TestEncodeInjectMetadata(t *testing.T) {
+	tenant := &example.Tenant{Id: 1, Name: "goravel"}
+	data, err := proto.Marshal(tenant)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name         string
+		key          string
+		value        any
+		expectValues []string
+	}{
+		{
+			name:         "Happy path - scalar",
+			key:          "user-id",
+			value:        1,
+			expectValues: []string{"1"},
+		},
+		{
+			name:         "Happy path - slice",
+			key:          "role-ids",
+			value:        []int{1, 2},
+			expectValues: []string{"1", "2"},
+		},
+		{
+			name:         "Happy path - proto.Message",
+			key:          "tenant",
+			value:        tenant,
+			expectValues: []string{`{"id":1,"name":"goravel"}`},
+		},
+		{
+			name:         "Happy path - binary proto.Message",
+			key:          "tenant-bin",
+			value:        tenant,
+			expectValues: []string{base64.StdEncoding.EncodeToString(data)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := encodeInjectMetadata(test.key, test.value)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectValues, values)
+		})
+	}
+}
+
+func TestKeyAliases(t *testing.T) {
+	assert.Equal(t, []string{"user_id", "userId"}, keyAliases("user_id"))
+	assert.Equal(t, []string{"userId", "user_id"}, keyAliases("userId"))
+	assert.Equal(t, []string{"tenant.owner_id", "tenant.ownerId"}, keyAliases("tenant.owner_id"))
+}
+
+func TestStripQuery(t *testing.T) {
+	query := url.Values{
+		"user_id":        {"1"},
+		"userId":         {"2"},
+		"user_id.nested": {"3"},
+		"user_ids":       {"4"},
+	}
+	stripQuery(query, "user_id")
+
+	assert.Equal(t, url.Values{"user_ids": {"4"}}, query)
+}
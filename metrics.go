@@ -0,0 +1,88 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/metadata"
+)
+
+// metrics records the requests served by the ServeMux, they are exposed on gateway.metrics.path in the Prometheus
+// text exposition format.
+type metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inflight *prometheus.GaugeVec
+}
+
+func newMetrics() *metrics {
+	labels := []string{"service", "method", "http_method", "route"}
+	metrics := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_requests_total",
+			Help: "The total number of requests proxied by the Gateway.",
+		}, append(labels, "status")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gateway_request_duration_seconds",
+			Help:    "The latency of requests proxied by the Gateway.",
+			Buckets: prometheus.DefBuckets,
+		}, append(labels, "status")),
+		inflight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gateway_requests_in_flight",
+			Help: "The number of requests being proxied by the Gateway.",
+		}, labels),
+	}
+	metrics.registry.MustRegister(metrics.requests, metrics.duration, metrics.inflight)
+
+	return metrics
+}
+
+// register serves the metrics on path, observe and annotate should be registered to the ServeMux after
+// registerRequestInfo.
+func (r *metrics) register(mux *runtime.ServeMux, path string) error {
+	handler := promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+
+	return mux.HandlePath(http.MethodGet, path, func(w http.ResponseWriter, req *http.Request, _ map[string]string) {
+		handler.ServeHTTP(w, req)
+	})
+}
+
+func (r *metrics) observe(info *requestInfo) {
+	labels := prometheus.Labels{
+		"service":     info.service,
+		"method":      info.method,
+		"http_method": info.httpMethod,
+		"route":       info.route,
+	}
+	if info.method != "" {
+		r.inflight.With(labels).Dec()
+	}
+
+	labels["status"] = strconv.Itoa(info.writer.status)
+	r.requests.With(labels).Inc()
+	r.duration.With(labels).Observe(time.Since(info.start).Seconds())
+}
+
+// annotate increases the in-flight gauge once the gRPC method is known, it's called after annotateRequestInfo.
+func (r *metrics) annotate(ctx context.Context, _ *http.Request) metadata.MD {
+	info := requestInfoFromContext(ctx)
+	if info == nil || info.method == "" {
+		return nil
+	}
+
+	r.inflight.With(prometheus.Labels{
+		"service":     info.service,
+		"method":      info.method,
+		"http_method": info.httpMethod,
+		"route":       info.route,
+	}).Inc()
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	configmocks "github.com/goravel/framework/mocks/config"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/goravel/gateway/proto/example"
+)
+
+func TestMetrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:4009")
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	example.RegisterUserServiceServer(server, NewUserController())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	facadesConfig := FacadesConfig
+	FacadesConfig = mockConfig
+	defer func() {
+		FacadesConfig = facadesConfig
+	}()
+
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return nil
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("/metrics")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(true)
+	mockConfig.On("Get", "gateway.coalesce.headers").Return(nil)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "example").Return(newTestConnection(t, "127.0.0.1:4009"), nil)
+
+	require.Nil(t, gateway.Run())
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	for _, path := range []string{"/users/1", "/users/1", "/users/0"} {
+		gateway.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	// The GET requests of the controller are counted by gateway.coalesce.
+	require.Nil(t, Get(NewTestContext(context.Background(), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))).Render())
+
+	// The scrapes aren't counted.
+	var body string
+	for range 2 {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		body = recorder.Body.String()
+	}
+	assert.NotContains(t, body, `route="/metrics"`)
+	assert.Contains(t, body, `gateway_requests_total{http_method="GET",method="GetUser",route="/users/{id}",service="example.UserService",status="200"} 2`)
+	assert.Contains(t, body, `gateway_requests_total{http_method="GET",method="GetUser",route="/users/{id}",service="example.UserService",status="404"} 1`)
+	assert.Contains(t, body, `gateway_request_duration_seconds_count{http_method="GET",method="GetUser",route="/users/{id}",service="example.UserService",status="200"} 2`)
+	assert.Contains(t, body, `gateway_coalesced_requests_total{result="leader"} 1`)
+	assert.Contains(t, body, `gateway_requests_in_flight{http_method="GET",method="GetUser",route="/users/{id}",service="example.UserService"} 0`)
+
+	mockConfig.AssertExpectations(t)
+	mockGrpc.AssertExpectations(t)
+}
@@ -0,0 +1,252 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/spf13/cast"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/goravel/gateway/contracts"
+)
+
+type RateLimitStore = contracts.RateLimitStore
+
+// RateLimit is a token bucket of gateway.rate_limit.limits, each client has its own bucket.
+type RateLimit struct {
+	// Method is the HTTP method of the limited requests, all methods are limited if it's empty.
+	Method string
+	// Route is the path pattern of the limited requests, for example, /users/{id}. A {variable} matches a path segment,
+	// and * matches all paths.
+	Route string
+	// Rate is the number of requests allowed per second.
+	Rate float64
+	// Burst is the number of requests allowed at once, it's the rounded up Rate if it's 0.
+	Burst int
+	// Identity is the key of a value injected by gateway.Inject, for example, user_id. The client is identified by the
+	// injected value instead of the IP if it's set, the requests sent to the Gateway directly are identified by the IP.
+	Identity string
+}
+
+// RateLimitError is passed to the fallback if the request exceeds a rate limit, the Retry-After header is already set.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (r *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", r.RetryAfter)
+}
+
+type rateLimitedContextKey struct{}
+
+// rateLimitOptions is the gateway.rate_limit configuration, it's nil if the rate limit is disabled.
+type rateLimitOptions struct {
+	store  RateLimitStore
+	limits []RateLimit
+}
+
+// rateLimiter applies the limits to the requests of the controller and the requests sent to the Gateway directly.
+type rateLimiter struct {
+	store  RateLimitStore
+	limits []RateLimit
+}
+
+// newRateLimiter returns nil if the rate limit is disabled or there is no limit, a nil *rateLimiter allows all
+// requests. The buckets are kept in memory if gateway.rate_limit.store isn't set.
+func newRateLimiter(options *rateLimitOptions) *rateLimiter {
+	if options == nil || len(options.limits) == 0 {
+		return nil
+	}
+
+	store := options.store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+
+	return &rateLimiter{store: store, limits: options.limits}
+}
+
+// take takes a token from the bucket of each matched limit, identity returns the client of a limit. The error is
+// a *RateLimitError if a bucket is empty. The request is allowed if the store fails, the Gateway keeps working when a
+// shared store is down.
+func (r *rateLimiter) take(ctx context.Context, method, path string, identity func(limit RateLimit) string) error {
+	if r == nil {
+		return nil
+	}
+
+	for i, limit := range r.limits {
+		if !limit.matches(method, path) {
+			continue
+		}
+
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(limit.Rate))
+		}
+
+		key := fmt.Sprintf("gateway:rate_limit:%d:%s %s:%s", i, limit.Method, limit.Route, identity(limit))
+		allowed, retryAfter, err := r.store.Take(ctx, key, limit.Rate, burst)
+		if err == nil && !allowed {
+			return &RateLimitError{RetryAfter: retryAfter}
+		}
+	}
+
+	return nil
+}
+
+// controller applies the limits to a request of the controller.
+func (r *rateLimiter) controller(ctx contractshttp.Context, method string, injected map[string]any) error {
+	return r.take(ctx.Context(), method, ctx.Request().Path(), func(limit RateLimit) string {
+		if value, exist := injected[limit.Identity]; exist && limit.Identity != "" {
+			return "identity:" + cast.ToString(value)
+		}
+
+		return "ip:" + ctx.Request().Ip()
+	})
+}
+
+// rateLimited marks the proxied request of the controller, it's already limited by the controller so the Gateway
+// running in the current process doesn't limit it again.
+func rateLimited(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rateLimitedContextKey{}, true)
+}
+
+// middleware applies the limits to the requests sent to the Gateway directly, they are identified by the IP.
+func (r *rateLimiter) middleware(mux *runtime.ServeMux) runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+			if limited, _ := req.Context().Value(rateLimitedContextKey{}).(bool); limited {
+				next(w, req, params)
+				return
+			}
+
+			err := r.take(req.Context(), req.Method, req.URL.Path, func(RateLimit) string {
+				return "ip:" + remoteIP(req)
+			})
+			if rateLimitErr, ok := err.(*RateLimitError); ok {
+				w.Header().Set("Retry-After", retryAfterSeconds(rateLimitErr.RetryAfter))
+
+				_, outbound := runtime.MarshalerForRequest(mux, req)
+				runtime.HTTPError(req.Context(), mux, outbound, w, req, status.Error(codes.ResourceExhausted, err.Error()))
+				return
+			}
+
+			next(w, req, params)
+		}
+	}
+}
+
+func (r RateLimit) matches(method, path string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	if r.Route == "*" {
+		return true
+	}
+
+	routeSegments := strings.Split(strings.Trim(r.Route, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(routeSegments) != len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range routeSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+// retryAfterSeconds formats the Retry-After header, it's rounded up to seconds.
+func retryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(max(int(math.Ceil(retryAfter.Seconds())), 1))
+}
+
+// MemoryRateLimitStore keeps the token buckets in memory, it's the default store of gateway.rate_limit. The buckets
+// aren't shared between the instances of the application.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	// full is the time when the bucket is full again, the bucket can be removed after it.
+	full time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		swept:   time.Now(),
+	}
+}
+
+func (r *MemoryRateLimitStore) Take(_ context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.sweep(now)
+
+	bucket, exist := r.buckets[key]
+	if !exist {
+		bucket = &tokenBucket{tokens: float64(burst), updated: now}
+		r.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(burst), bucket.tokens+now.Sub(bucket.updated).Seconds()*rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / rate * float64(time.Second)), nil
+	}
+
+	bucket.tokens--
+	bucket.full = now.Add(time.Duration((float64(burst) - bucket.tokens) / rate * float64(time.Second)))
+
+	return true, 0, nil
+}
+
+// sweep removes the full buckets once a minute, they are the same as new buckets.
+func (r *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(r.swept) < time.Minute {
+		return
+	}
+
+	for key, bucket := range r.buckets {
+		if now.After(bucket.full) {
+			delete(r.buckets, key)
+		}
+	}
+	r.swept = now
+}
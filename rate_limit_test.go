@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	configmocks "github.com/goravel/framework/mocks/config"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/goravel/gateway/proto/example"
+)
+
+func TestRateLimit(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:4013")
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	example.RegisterUserServiceServer(server, NewUserController())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	facadesConfig := FacadesConfig
+	FacadesConfig = mockConfig
+	defer func() {
+		FacadesConfig = facadesConfig
+	}()
+
+	store := &keyRecorder{RateLimitStore: NewMemoryRateLimitStore()}
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return ctx.Response().Data(HTTPStatusFromError(err), "text/plain", []byte(err.Error()))
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(true)
+	mockConfig.On("Get", "gateway.rate_limit.store").Return(store)
+	mockConfig.On("Get", "gateway.rate_limit.limits").Return([]RateLimit{
+		{Method: http.MethodGet, Route: "/users/{id}", Rate: 0.01, Burst: 1, Identity: "user_id"},
+	})
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(false)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "example").Return(newTestConnection(t, "127.0.0.1:4013"), nil)
+
+	require.Nil(t, gateway.Run())
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	t.Run("Controller", func(t *testing.T) {
+		store.keys = nil
+
+		for _, expectStatus := range []int{http.StatusOK, http.StatusTooManyRequests} {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			require.Nil(t, Get(NewTestContext(context.Background(), recorder, req)).Render())
+
+			assert.Equal(t, expectStatus, recorder.Code)
+			if expectStatus == http.StatusTooManyRequests {
+				assert.Equal(t, "100", recorder.Header().Get("Retry-After"))
+			}
+		}
+
+		// The client is identified by the injected user_id, and the Gateway doesn't limit the request again.
+		assert.Equal(t, []string{
+			"gateway:rate_limit:0:GET /users/{id}:identity:2",
+			"gateway:rate_limit:0:GET /users/{id}:identity:2",
+		}, store.keys)
+	})
+
+	t.Run("Gateway", func(t *testing.T) {
+		store.keys = nil
+
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		recorder = httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/2", nil))
+		assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		assert.Equal(t, "100", recorder.Header().Get("Retry-After"))
+		code, _, ok := grpcStatusFromBody(recorder.Body.Bytes())
+		assert.True(t, ok)
+		assert.Equal(t, codes.ResourceExhausted, code)
+
+		// The route isn't limited.
+		recorder = httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "goravel"}`)))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		assert.Equal(t, []string{
+			"gateway:rate_limit:0:GET /users/{id}:ip:192.0.2.1",
+			"gateway:rate_limit:0:GET /users/{id}:ip:192.0.2.1",
+		}, store.keys)
+	})
+
+	mockGrpc.AssertExpectations(t)
+}
+
+func TestRateLimitMatches(t *testing.T) {
+	tests := []struct {
+		limit  RateLimit
+		method string
+		path   string
+		expect bool
+	}{
+		{RateLimit{Route: "*"}, http.MethodPost, "/users", true},
+		{RateLimit{Route: "/users/{id}"}, http.MethodGet, "/users/1", true},
+		{RateLimit{Route: "/users/{id}"}, http.MethodGet, "/users/1/", true},
+		{RateLimit{Route: "/users/{id}", Method: "get"}, http.MethodGet, "/users/1", true},
+		{RateLimit{Route: "/users/{id}", Method: http.MethodPut}, http.MethodGet, "/users/1", false},
+		{RateLimit{Route: "/users/{id}"}, http.MethodGet, "/users", false},
+		{RateLimit{Route: "/users/{id}"}, http.MethodGet, "/users/1/roles", false},
+		{RateLimit{Route: "/users/{id}"}, http.MethodGet, "/roles/1", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expect, test.limit.matches(test.method, test.path), "%s %s %s", test.limit.Route, test.method, test.path)
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	ctx := context.Background()
+
+	for range 2 {
+		allowed, retryAfter, err := store.Take(ctx, "user", 100, 2)
+		assert.Nil(t, err)
+		assert.True(t, allowed)
+		assert.Zero(t, retryAfter)
+	}
+
+	allowed, retryAfter, err := store.Take(ctx, "user", 100, 2)
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+	assert.Greater(t, retryAfter, time.Duration(0))
+	assert.LessOrEqual(t, retryAfter, 10*time.Millisecond)
+
+	// Each key has its own bucket.
+	allowed, _, err = store.Take(ctx, "role", 100, 2)
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	time.Sleep(retryAfter)
+	allowed, _, err = store.Take(ctx, "user", 100, 2)
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	// The full buckets are swept.
+	store.swept = time.Now().Add(-time.Minute)
+	store.buckets["role"].full = time.Now().Add(-time.Second)
+	_, _, err = store.Take(ctx, "user", 100, 2)
+	assert.Nil(t, err)
+	assert.NotContains(t, store.buckets, "role")
+	assert.Contains(t, store.buckets, "user")
+}
+
+// keyRecorder records the keys of the buckets that are taken.
+type keyRecorder struct {
+	RateLimitStore
+
+	mu   sync.Mutex
+	keys []string
+}
+
+func (r *keyRecorder) Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	r.mu.Lock()
+	r.keys = append(r.keys, key)
+	r.mu.Unlock()
+
+	return r.RateLimitStore.Take(ctx, key, rate, burst)
+}
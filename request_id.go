@@ -0,0 +1,47 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// RequestID returns the request id of the controller request, it can be used in gateway.fallback to add the id to
+// error payloads. It's the X-Request-Id header sent by the client, or a generated UUID if the client doesn't send it.
+func RequestID(ctx contractshttp.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+
+	return requestID
+}
+
+// withRequestID accepts the request id of the client or generates one, and echoes it in the response.
+func withRequestID(ctx contractshttp.Context) string {
+	requestID := ctx.Request().Header(RequestIDHeader)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+
+	ctx.WithValue(RequestIDKey, requestID)
+	ctx.Response().Header(RequestIDHeader, requestID)
+
+	return requestID
+}
+
+// requestIDMiddleware generates the request id if the request is sent to the Gateway directly without it, and echoes
+// it in the response. The id is forwarded as the x-request-id gRPC metadata via the Grpc-Metadata-X-Request-Id header,
+// which runtime.DefaultHeaderMatcher forwards, so the header matcher of the ServeMux isn't replaced.
+func requestIDMiddleware(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		requestID := req.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		req.Header.Set(runtime.MetadataHeaderPrefix+RequestIDHeader, requestID)
+		w.Header().Set(RequestIDHeader, requestID)
+
+		next(w, req, params)
+	}
+}
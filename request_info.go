@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// maxErrorBody is the size of the error body kept by responseRecorder, it's enough for a google.rpc.Status message.
+const maxErrorBody = 4 << 10
+
+type requestInfoContextKey struct{}
+
+// requestInfo is collected for each request served by the ServeMux, the metrics and the access log are built from it.
+type requestInfo struct {
+	start      time.Time
+	httpMethod string
+	path       string
+	requestID  string
+	// route is the path template, service and method are the gRPC method, they are filled when the generated handler
+	// annotates the context.
+	route   string
+	service string
+	method  string
+
+	writer *responseRecorder
+}
+
+func requestInfoFromContext(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoContextKey{}).(*requestInfo)
+
+	return info
+}
+
+// grpcMethod returns the full gRPC method without the leading slash, for example, example.UserService/GetUser.
+func (r *requestInfo) grpcMethod() string {
+	if r.method == "" {
+		return ""
+	}
+
+	return r.service + "/" + r.method
+}
+
+// grpcStatus returns codes.OK for successful responses, otherwise the code and the message of the google.rpc.Status
+// body.
+func (r *requestInfo) grpcStatus() (codes.Code, string) {
+	if r.writer.status < http.StatusBadRequest {
+		return codes.OK, ""
+	}
+
+	code, message, _ := grpcStatusFromBody(r.writer.errorBody.Bytes())
+
+	return code, message
+}
+
+// registerRequestInfo applies the middleware that collects requestInfo to the ServeMux, the observers are called when
+// the request is finished.
+func registerRequestInfo(mux *runtime.ServeMux, observers ...func(info *requestInfo)) {
+	runtime.WithMiddlewares(func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+			info := &requestInfo{
+				start:      time.Now(),
+				httpMethod: req.Method,
+				path:       req.URL.Path,
+				requestID:  req.Header.Get(RequestIDHeader),
+				writer:     newResponseRecorder(w),
+			}
+			// The route of a request that doesn't reach the generated handler, the backend is unavailable for example.
+			if pattern, ok := runtime.HTTPPattern(req.Context()); ok {
+				info.route = pattern.String()
+			}
+
+			next(info.writer, req.WithContext(context.WithValue(req.Context(), requestInfoContextKey{}, info)), params)
+
+			for _, observer := range observers {
+				observer(info)
+			}
+		}
+	})(mux)
+	runtime.WithMetadata(annotateRequestInfo)(mux)
+}
+
+// annotateRequestInfo is called by the generated handler with the gRPC method and the route pattern in the context,
+// it doesn't add any metadata.
+func annotateRequestInfo(ctx context.Context, _ *http.Request) metadata.MD {
+	info := requestInfoFromContext(ctx)
+	if info == nil {
+		return nil
+	}
+
+	if rpcMethod, ok := runtime.RPCMethod(ctx); ok {
+		// The full method is /package.Service/Method.
+		info.service, info.method, _ = strings.Cut(strings.TrimPrefix(rpcMethod, "/"), "/")
+	}
+	if route, ok := runtime.HTTPPathPattern(ctx); ok {
+		info.route = route
+	}
+
+	return nil
+}
+
+// responseRecorder records the status and the size of the response written by the handler, and the beginning of the
+// body if the status isn't successful.
+type responseRecorder struct {
+	http.ResponseWriter
+	status    int
+	bytes     int
+	errorBody bytes.Buffer
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.status >= http.StatusBadRequest && r.errorBody.Len() < maxErrorBody {
+		r.errorBody.Write(data[:min(len(data), maxErrorBody-r.errorBody.Len())])
+	}
+
+	n, err := r.ResponseWriter.Write(data)
+	r.bytes += n
+
+	return n, err
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	defaultRetryCodes   = []codes.Code{codes.Unavailable}
+	defaultRetryMethods = []string{http.MethodGet, http.MethodDelete}
+)
+
+// retryPolicy is the gateway.retry configuration, a request is sent once if maxAttempts is 1.
+type retryPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	codes       []codes.Code
+	methods     []string
+}
+
+// Idempotent marks the current request as idempotent, so it's retried by gateway.retry even if its HTTP method isn't
+// in gateway.retry.methods. It can be called in a middleware before the controller.
+func Idempotent(ctx contractshttp.Context) {
+	ctx.WithValue(IdempotentKey, true)
+}
+
+// allows reports whether the request can be retried, the body of an allowed request is buffered.
+func (r *retryPolicy) allows(ctx contractshttp.Context, method string) bool {
+	if r.maxAttempts <= 1 {
+		return false
+	}
+
+	idempotent, _ := ctx.Value(IdempotentKey).(bool)
+
+	return idempotent || slices.ContainsFunc(r.methods, func(retryMethod string) bool {
+		return strings.EqualFold(retryMethod, method)
+	})
+}
+
+// retryable reports whether the attempt failed transiently: the Gateway can't be reached, or it answers with a gRPC
+// code of gateway.retry.codes. The request isn't retried if its context is done or the circuit breaker is open.
+func (r *retryPolicy) retryable(resp *http.Response, body []byte, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return false
+	}
+
+	// The backend isn't called if its circuit breaker is open, retrying it doesn't help.
+	if _, open := circuitOpenFromBody(body); open {
+		return false
+	}
+
+	code, _, ok := grpcStatusFromBody(body)
+
+	return ok && slices.Contains(r.codes, code)
+}
+
+// wait sleeps before the next attempt, the backoff doubles after each attempt up to maxBackoff, and a random jitter
+// is applied so that the clients don't retry at the same time.
+func (r *retryPolicy) wait(ctx context.Context, attempt int) error {
+	backoff := r.backoff
+	for i := 1; i < attempt && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, r.maxBackoff)
+	if backoff > 0 {
+		backoff = time.Duration(rand.Int64N(int64(backoff) + 1))
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
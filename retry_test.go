@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRetryable(t *testing.T) {
+	policy := &retryPolicy{maxAttempts: 3, codes: defaultRetryCodes}
+
+	tests := []struct {
+		name   string
+		resp   *http.Response
+		body   string
+		err    error
+		expect bool
+	}{
+		{
+			name:   "The Gateway can't be reached",
+			err:    errors.New("connection refused"),
+			expect: true,
+		},
+		{
+			name: "The request is canceled",
+			err:  context.Canceled,
+		},
+		{
+			name: "The deadline is exceeded",
+			err:  context.DeadlineExceeded,
+		},
+		{
+			name: "Succeeded",
+			resp: &http.Response{StatusCode: http.StatusOK},
+		},
+		{
+			name:   "Retryable code",
+			resp:   &http.Response{StatusCode: http.StatusServiceUnavailable},
+			body:   `{"code":14,"message":"unavailable"}`,
+			expect: true,
+		},
+		{
+			name: "Not retryable code",
+			resp: &http.Response{StatusCode: http.StatusNotFound},
+			body: `{"code":5,"message":"not found"}`,
+		},
+		{
+			name: "Not a gRPC status",
+			resp: &http.Response{StatusCode: http.StatusServiceUnavailable},
+			body: "unavailable",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, policy.retryable(test.resp, []byte(test.body), test.err))
+		})
+	}
+
+	policy.codes = []codes.Code{codes.NotFound}
+	assert.True(t, policy.retryable(&http.Response{StatusCode: http.StatusNotFound}, []byte(`{"code":5}`), nil))
+}
+
+func TestRetryWait(t *testing.T) {
+	policy := &retryPolicy{maxAttempts: 5, backoff: 10 * time.Millisecond, maxBackoff: 20 * time.Millisecond}
+
+	start := time.Now()
+	assert.Nil(t, policy.wait(context.Background(), 10))
+	assert.LessOrEqual(t, time.Since(start), time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	policy.backoff, policy.maxBackoff = time.Hour, time.Hour
+	assert.ErrorIs(t, policy.wait(ctx, 1), context.Canceled)
+}
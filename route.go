@@ -0,0 +1,193 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/route"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// templateVariable matches the variables of a path template, {id} or {id=*}, other patterns such as
+// {name=projects/*} can't be expressed by Goravel routes.
+var templateVariable = regexp.MustCompile(`\{([^}=]+)(=\*)?}`)
+
+// Routes registers a Goravel route for every google.api.http rule of the services in the proto registry, services are
+// the full names of the services, for example, example.UserService, all services are used if it's empty. The
+// middleware, the timeout and the idempotency of an Api are applied to the route that has the same Method and Url.
+// An unsupported rule returns an error if its service is in services, otherwise it's skipped with a warning, so the
+// third-party protos linked into the app don't fail the registration.
+func Routes(router route.Router, apis []Api, services ...string) error {
+	return registerRoutes(router, apis, protoregistry.GlobalFiles, services)
+}
+
+func registerRoutes(router route.Router, apis []Api, files *protoregistry.Files, services []string) error {
+	overrides := make(map[string]Api, len(apis))
+	for _, api := range apis {
+		overrides[routeKey(api.Method, api.Url)] = api
+	}
+
+	var routes []Api
+	var err error
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		for i := 0; i < file.Services().Len(); i++ {
+			service := file.Services().Get(i)
+			if len(services) > 0 && !containsService(services, service.FullName()) {
+				continue
+			}
+
+			apis, serviceErr := serviceRoutes(service)
+			if serviceErr != nil {
+				if len(services) > 0 {
+					err = serviceErr
+					return false
+				}
+
+				color.Yellowln(fmt.Sprintf("[Gateway] Skip the unsupported routes: %v", serviceErr))
+			}
+
+			routes = append(routes, apis...)
+		}
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	registered := make(map[string]bool, len(routes))
+	for _, api := range routes {
+		key := routeKey(api.Method, api.Url)
+		if registered[key] {
+			continue
+		}
+		registered[key] = true
+
+		override := overrides[key]
+		routeRouter := router
+		if len(override.Middleware) > 0 {
+			routeRouter = router.Middleware(override.Middleware...)
+		}
+
+		switch api.Method {
+		case http.MethodGet:
+			routeRouter.Get(api.Url, routeHandler(Get, api, override))
+		case http.MethodPost:
+			routeRouter.Post(api.Url, routeHandler(Post, api, override))
+		case http.MethodPut:
+			routeRouter.Put(api.Url, routeHandler(Put, api, override))
+		case http.MethodDelete:
+			routeRouter.Delete(api.Url, routeHandler(Delete, api, override))
+		case http.MethodPatch:
+			routeRouter.Patch(api.Url, routeHandler(Patch, api, override))
+		}
+	}
+
+	return nil
+}
+
+// serviceRoutes returns the routes of the google.api.http rules of the service, including additional bindings. The
+// error contains all unsupported rules, the routes of the other rules are still returned.
+func serviceRoutes(service protoreflect.ServiceDescriptor) ([]Api, error) {
+	var (
+		routes []Api
+		errs   []error
+	)
+	for i := 0; i < service.Methods().Len(); i++ {
+		method := service.Methods().Get(i)
+		rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+
+		options, _ := method.Options().(*descriptorpb.MethodOptions)
+		idempotency := options.GetIdempotencyLevel()
+		idempotent := idempotency == descriptorpb.MethodOptions_IDEMPOTENT || idempotency == descriptorpb.MethodOptions_NO_SIDE_EFFECTS
+
+		for _, rule := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+			api, err := ruleRoute(rule)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("register route of %s failed: %v", method.FullName(), err))
+				continue
+			}
+
+			api.Idempotent = idempotent
+			api.stream = method.IsStreamingServer()
+			routes = append(routes, api)
+		}
+	}
+
+	return routes, errors.Join(errs...)
+}
+
+func ruleRoute(rule *annotations.HttpRule) (Api, error) {
+	var method, template string
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		method, template = http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Post:
+		method, template = http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Put:
+		method, template = http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Delete:
+		method, template = http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		method, template = http.MethodPatch, pattern.Patch
+	default:
+		return Api{}, fmt.Errorf("the custom HTTP method is not supported")
+	}
+
+	url := templateVariable.ReplaceAllString(template, "{$1}")
+	if strings.ContainsAny(url, "*=:") {
+		return Api{}, fmt.Errorf("the path template %s is not supported", template)
+	}
+
+	return Api{Method: method, Url: url}, nil
+}
+
+// routeHandler applies the timeout and the idempotency of the route before calling handler, api is the route of the
+// proto and override is the Api passed to Routes. A stream lasts as long as the client keeps it, so gateway.timeout
+// isn't applied to it unless a middleware sets the timeout.
+func routeHandler(handler contractshttp.HandlerFunc, api, override Api) contractshttp.HandlerFunc {
+	idempotent := api.Idempotent || override.Idempotent
+	if override.Timeout <= 0 && !idempotent && !api.stream {
+		return handler
+	}
+
+	return func(ctx contractshttp.Context) contractshttp.Response {
+		if override.Timeout > 0 {
+			Timeout(ctx, override.Timeout)
+		} else if _, ok := ctx.Value(TimeoutKey).(time.Duration); api.stream && !ok {
+			Timeout(ctx, 0)
+		}
+		if idempotent {
+			Idempotent(ctx)
+		}
+
+		return handler(ctx)
+	}
+}
+
+func containsService(services []string, service protoreflect.FullName) bool {
+	for _, name := range services {
+		if protoreflect.FullName(name) == service {
+			return true
+		}
+	}
+
+	return false
+}
+
+func routeKey(method, url string) string {
+	return strings.ToUpper(method) + " " + url
+}
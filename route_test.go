@@ -0,0 +1,206 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	httpmocks "github.com/goravel/framework/mocks/http"
+	routemocks "github.com/goravel/framework/mocks/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	_ "github.com/goravel/gateway/proto/example"
+)
+
+func TestRoutes(t *testing.T) {
+	mockRouter := routemocks.NewRouter(t)
+	mockMiddlewareRouter := routemocks.NewRouter(t)
+	mockMiddleware := httpmocks.NewMiddleware(t)
+
+	mockRouter.EXPECT().Get("/users", mock.Anything).Return(nil).Once()
+	mockRouter.EXPECT().Get("/users/{id}", mock.Anything).Return(nil).Once()
+	mockRouter.EXPECT().Put("/users/{id}", mock.Anything).Return(nil).Once()
+	mockRouter.EXPECT().Delete("/users/{id}", mock.Anything).Return(nil).Once()
+	mockRouter.EXPECT().Get("/users/watch", mock.Anything).Return(nil).Once()
+	mockRouter.EXPECT().Middleware(mockMiddleware).Return(mockMiddlewareRouter).Once()
+	mockMiddlewareRouter.EXPECT().Post("/users", mock.Anything).Return(nil).Once()
+
+	assert.Nil(t, Routes(mockRouter, []Api{
+		{Method: "post", Url: "/users", Middleware: []contractshttp.Middleware{mockMiddleware}},
+		{Method: "get", Url: "/users/{id}", Timeout: time.Second},
+	}, "example.UserService"))
+}
+
+func TestRoutesWithUnsupportedRules(t *testing.T) {
+	methodOptions := func(rule *annotations.HttpRule) *descriptorpb.MethodOptions {
+		options := &descriptorpb.MethodOptions{}
+		proto.SetExtension(options, annotations.E_Http, rule)
+
+		return options
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("thirdparty/project.proto"),
+		Package:     proto.String("thirdparty"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Project")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("ProjectService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("GetProject"),
+					InputType:  proto.String(".thirdparty.Project"),
+					OutputType: proto.String(".thirdparty.Project"),
+					Options:    methodOptions(&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=projects/*}"}}),
+				},
+				{
+					Name:       proto.String("ListProjects"),
+					InputType:  proto.String(".thirdparty.Project"),
+					OutputType: proto.String(".thirdparty.Project"),
+					Options:    methodOptions(&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/projects"}}),
+				},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.Nil(t, err)
+	files := new(protoregistry.Files)
+	require.Nil(t, files.RegisterFile(file))
+
+	// The unsupported rule is skipped if the service isn't named.
+	mockRouter := routemocks.NewRouter(t)
+	mockRouter.EXPECT().Get("/v1/projects", mock.Anything).Return(nil).Once()
+	assert.Nil(t, registerRoutes(mockRouter, nil, files, nil))
+
+	assert.EqualError(t, registerRoutes(routemocks.NewRouter(t), nil, files, []string{"thirdparty.ProjectService"}),
+		"register route of thirdparty.ProjectService.GetProject failed: the path template /v1/{name=projects/*} is not supported")
+}
+
+func TestServiceRoutes(t *testing.T) {
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName("example.UserService")
+	assert.Nil(t, err)
+
+	routes, err := serviceRoutes(descriptor.(protoreflect.ServiceDescriptor))
+	assert.Nil(t, err)
+	assert.Equal(t, []Api{
+		{Method: "GET", Url: "/users"},
+		{Method: "GET", Url: "/users/{id}"},
+		{Method: "POST", Url: "/users"},
+		{Method: "PUT", Url: "/users/{id}", Idempotent: true},
+		{Method: "DELETE", Url: "/users/{id}"},
+		{Method: "GET", Url: "/users/watch", stream: true},
+	}, routes)
+}
+
+func TestRouteHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		api           Api
+		override      Api
+		middleware    func(ctx contractshttp.Context)
+		expectTimeout any
+	}{
+		{
+			name: "Unary",
+			api:  Api{Method: "GET", Url: "/users/{id}"},
+		},
+		{
+			name:          "Override the timeout",
+			api:           Api{Method: "GET", Url: "/users/{id}"},
+			override:      Api{Timeout: time.Second},
+			expectTimeout: time.Second,
+		},
+		{
+			name:          "Disable gateway.timeout for a stream",
+			api:           Api{Method: "GET", Url: "/users/watch", stream: true},
+			expectTimeout: time.Duration(0),
+		},
+		{
+			name: "Keep the timeout of the middleware for a stream",
+			api:  Api{Method: "GET", Url: "/users/watch", stream: true},
+			middleware: func(ctx contractshttp.Context) {
+				Timeout(ctx, time.Minute)
+			},
+			expectTimeout: time.Minute,
+		},
+		{
+			name:          "Override the timeout of a stream",
+			api:           Api{Method: "GET", Url: "/users/watch", stream: true},
+			override:      Api{Timeout: time.Second},
+			expectTimeout: time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := NewTestContext(context.Background(), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.api.Url, nil))
+			if test.middleware != nil {
+				test.middleware(ctx)
+			}
+
+			var timeout any
+			routeHandler(func(ctx contractshttp.Context) contractshttp.Response {
+				timeout = ctx.Value(TimeoutKey)
+				return nil
+			}, test.api, test.override)(ctx)
+			assert.Equal(t, test.expectTimeout, timeout)
+		})
+	}
+}
+
+func TestRuleRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      *annotations.HttpRule
+		expectApi Api
+		expectErr string
+	}{
+		{
+			name:      "Happy path",
+			rule:      &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/users/{id}"}},
+			expectApi: Api{Method: "GET", Url: "/users/{id}"},
+		},
+		{
+			name:      "Happy path - single segment pattern",
+			rule:      &annotations.HttpRule{Pattern: &annotations.HttpRule_Patch{Patch: "/users/{id=*}/tenants/{tenant.id}"}},
+			expectApi: Api{Method: "PATCH", Url: "/users/{id}/tenants/{tenant.id}"},
+		},
+		{
+			name:      "error, multiple segments pattern",
+			rule:      &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=projects/*}"}},
+			expectErr: "the path template /v1/{name=projects/*} is not supported",
+		},
+		{
+			name:      "error, custom verb",
+			rule:      &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/users/{id}:undelete"}},
+			expectErr: "the path template /users/{id}:undelete is not supported",
+		},
+		{
+			name:      "error, custom method",
+			rule:      &annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: "HEAD", Path: "/users"}}},
+			expectErr: "the custom HTTP method is not supported",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api, err := ruleRoute(test.rule)
+			if test.expectErr != "" {
+				assert.EqualError(t, err, test.expectErr)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectApi, api)
+		})
+	}
+}
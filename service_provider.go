@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"github.com/goravel/framework/contracts/config"
+	"github.com/goravel/framework/contracts/console"
 	"github.com/goravel/framework/contracts/foundation"
 )
 
@@ -19,12 +20,18 @@ func (receiver *ServiceProvider) Register(app foundation.Application) {
 	App = app
 	FacadesConfig = app.MakeConfig()
 
-	app.Bind(Binding, func(app foundation.Application) (any, error) {
+	app.Singleton(Binding, func(app foundation.Application) (any, error) {
 		return NewGateway(app.MakeConfig(), app.MakeGrpc()), nil
 	})
 }
 
 func (receiver *ServiceProvider) Boot(app foundation.Application) {
+	if instance, err := app.Make(Binding); err == nil {
+		app.Commands([]console.Command{
+			NewValidateCommand(instance.(*Gateway)),
+		})
+	}
+
 	app.Publishes("github.com/goravel/gateway", map[string]string{
 		"config/gateway.go": app.ConfigPath("gateway.go"),
 	})
@@ -0,0 +1,178 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// streamHeader marks the responses of server-streaming methods, the Gateway sets it so that the controller can tell
+// them from the unary responses. The controller sends it in the request to ask for the marker, the responses to the
+// other clients aren't marked.
+const streamHeader = "X-Gateway-Stream"
+
+// streamMiddleware marks the response if the handler streams it, grpc-gateway sets Transfer-Encoding to chunked
+// before writing the first message of a server-streaming method.
+func streamMiddleware(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		if req.Header.Get(streamHeader) == "" {
+			next(w, req, params)
+			return
+		}
+
+		next(&streamMarker{ResponseWriter: w}, req, params)
+	}
+}
+
+type streamMarker struct {
+	http.ResponseWriter
+	marked bool
+}
+
+func (r *streamMarker) WriteHeader(status int) {
+	r.mark()
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *streamMarker) Write(data []byte) (int, error) {
+	r.mark()
+
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *streamMarker) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *streamMarker) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *streamMarker) mark() {
+	if r.marked {
+		return
+	}
+
+	r.marked = true
+	if r.Header().Get("Transfer-Encoding") == "chunked" {
+		r.Header().Set(streamHeader, "true")
+	}
+}
+
+// isStream reports whether the response is a successful stream, its body is read by the controller as the messages
+// arrive instead of being buffered.
+func isStream(resp *http.Response) bool {
+	return resp.Header.Get(streamHeader) != "" && resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
+}
+
+// stream writes the messages of the response to the client as they arrive, finish is called once the stream ends. The
+// messages are newline-delimited JSON by default, they are encoded as Server-Sent Events if the client accepts
+// text/event-stream and the messages are JSON.
+func stream(ctx contractshttp.Context, gatewayResp *http.Response, entry *accessLogEntry, finish func()) contractshttp.Response {
+	header := ctx.Response().Writer().Header()
+	header.Del("Content-Length")
+
+	events := strings.Contains(ctx.Request().Header("Accept"), MIMEEventStream) &&
+		strings.HasPrefix(gatewayResp.Header.Get("Content-Type"), MIMEJSON)
+	if events {
+		header.Set("Content-Type", MIMEEventStream)
+		header.Set("Cache-Control", "no-cache")
+	}
+
+	entry.status = gatewayResp.StatusCode
+
+	return ctx.Response().Stream(gatewayResp.StatusCode, func(w contractshttp.StreamWriter) error {
+		defer finish()
+		defer func() {
+			_ = gatewayResp.Body.Close()
+		}()
+
+		var err error
+		if events {
+			err = writeEvents(w, gatewayResp.Body, entry)
+		} else {
+			err = writeChunks(w, gatewayResp.Body, entry)
+		}
+		if err != nil {
+			entry.err = err
+		}
+
+		return err
+	})
+}
+
+// writeChunks copies the body and flushes each chunk, grpc-gateway flushes each message, so a chunk is usually a
+// message.
+func writeChunks(w contractshttp.StreamWriter, body io.Reader, entry *accessLogEntry) error {
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			written, writeErr := w.Write(buf[:n])
+			entry.bytes += written
+			if writeErr != nil {
+				return writeErr
+			}
+			if flushErr := w.Flush(); flushErr != nil {
+				return flushErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeEvents writes each message as an event, the result of a message is the data of a message event, and the error
+// that ends a stream is the data of an error event.
+func writeEvents(w contractshttp.StreamWriter, body io.Reader, entry *accessLogEntry) error {
+	decoder := json.NewDecoder(body)
+	for {
+		var message struct {
+			Result json.RawMessage `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		}
+		err := decoder.Decode(&message)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		// The data of an event can't contain line breaks.
+		var event bytes.Buffer
+		if message.Error != nil {
+			event.WriteString("event: error\ndata: ")
+			err = json.Compact(&event, message.Error)
+		} else {
+			event.WriteString("event: message\ndata: ")
+			err = json.Compact(&event, message.Result)
+		}
+		if err != nil {
+			return err
+		}
+		event.WriteString("\n\n")
+
+		written, err := w.Write(event.Bytes())
+		entry.bytes += written
+		if err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	configmocks "github.com/goravel/framework/mocks/config"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/goravel/gateway/proto/example"
+)
+
+func TestStream(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:4015")
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	example.RegisterUserServiceServer(server, NewUserController())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	facadesConfig := FacadesConfig
+	FacadesConfig = mockConfig
+	defer func() {
+		FacadesConfig = facadesConfig
+	}()
+
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return ctx.Response().Data(HTTPStatusFromError(err), "text/plain", []byte(err.Error()))
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(nil)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(true)
+	mockConfig.On("Get", "gateway.coalesce.headers").Return(nil)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "example").Return(newTestConnection(t, "127.0.0.1:4015"), nil)
+
+	require.Nil(t, gateway.Run())
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	get := func(url string, header map[string]string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		require.Nil(t, Get(NewTestContext(context.Background(), recorder, req)).Render())
+
+		return recorder
+	}
+
+	t.Run("Newline-delimited JSON", func(t *testing.T) {
+		recorder := get("/users/watch?user_id=2&count=3", nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.True(t, recorder.Flushed)
+		assert.Empty(t, recorder.Header().Get(streamHeader))
+		assert.Empty(t, recorder.Header().Get("Content-Length"))
+
+		lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+		require.Len(t, lines, 3)
+		for i, line := range lines {
+			var message struct {
+				Result struct {
+					User struct {
+						Id     int32 `json:"id"`
+						UserId int32 `json:"userId"`
+					} `json:"user"`
+				} `json:"result"`
+			}
+			require.Nil(t, json.Unmarshal([]byte(line), &message))
+			assert.Equal(t, int32(i+1), message.Result.User.Id)
+			assert.Equal(t, int32(2), message.Result.User.UserId)
+		}
+	})
+
+	t.Run("Server-Sent Events", func(t *testing.T) {
+		recorder := get("/users/watch?user_id=2&count=2", map[string]string{"Accept": MIMEEventStream})
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, MIMEEventStream, recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "no-cache", recorder.Header().Get("Cache-Control"))
+
+		events := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n\n"), "\n\n")
+		require.Len(t, events, 2)
+		for _, event := range events {
+			assert.True(t, strings.HasPrefix(event, "event: message\ndata: {\"user\":"), event)
+		}
+	})
+
+	t.Run("Fail before the first message", func(t *testing.T) {
+		recorder := get("/users/watch?count=0", nil)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, "gateway responded with status 400: count must be positive", recorder.Body.String())
+	})
+
+	t.Run("Gateway", func(t *testing.T) {
+		get := func(url string, marker bool) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			if marker {
+				req.Header.Set(streamHeader, "true")
+			}
+			gateway.ServeHTTP(recorder, req)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+
+			return recorder
+		}
+
+		assert.Equal(t, "true", get("/users/watch?count=1", true).Header().Get(streamHeader))
+
+		// The unary responses and the responses to the other clients aren't marked.
+		assert.Empty(t, get("/users/1", true).Header().Get(streamHeader))
+		assert.Empty(t, get("/users/watch?count=1", false).Header().Get(streamHeader))
+	})
+
+	mockGrpc.AssertExpectations(t)
+}
+
+func TestWriteEvents(t *testing.T) {
+	body := strings.NewReader(`{"result":{"user":{"id":1}}}
+{"result":{
+  "user":{"id":2}
+}}
+{"error":{"code":13,"message":"internal"}}
+`)
+	recorder := httptest.NewRecorder()
+	entry := &accessLogEntry{}
+
+	assert.Nil(t, writeEvents(&StreamWriter{recorder}, body, entry))
+	assert.Equal(t, `event: message
+data: {"user":{"id":1}}
+
+event: message
+data: {"user":{"id":2}}
+
+event: error
+data: {"code":13,"message":"internal"}
+
+`, recorder.Body.String())
+	assert.Equal(t, recorder.Body.Len(), entry.bytes)
+	assert.True(t, recorder.Flushed)
+
+	assert.NotNil(t, writeEvents(&StreamWriter{httptest.NewRecorder()}, strings.NewReader(`{"result":`), entry))
+}
@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+)
+
+// grpcTimeoutUnits are the units of the Grpc-Timeout header, the value can't exceed 8 digits.
+var grpcTimeoutUnits = []struct {
+	duration time.Duration
+	unit     string
+}{
+	{time.Nanosecond, "n"},
+	{time.Microsecond, "u"},
+	{time.Millisecond, "m"},
+	{time.Second, "S"},
+	{time.Minute, "M"},
+	{time.Hour, "H"},
+}
+
+// Timeout overrides gateway.timeout for the current request, it can be called in a middleware before the controller.
+// A timeout less than or equal to 0 disables the timeout of the request.
+func Timeout(ctx contractshttp.Context, timeout time.Duration) {
+	ctx.WithValue(TimeoutKey, timeout)
+}
+
+// requestContext derives the context of the proxied request from the incoming request, so a client disconnect
+// cancels the gRPC call, and applies the timeout of the route or gateway.timeout.
+func requestContext(ctx contractshttp.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if routeTimeout, ok := ctx.Value(TimeoutKey).(time.Duration); ok {
+		timeout = routeTimeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx.Context())
+	}
+
+	return context.WithTimeout(ctx.Context(), timeout)
+}
+
+// grpcTimeout encodes the time left before the deadline to the Grpc-Timeout header, the value is rounded up like
+// grpc-go does.
+func grpcTimeout(timeout time.Duration) string {
+	if timeout <= 0 {
+		return "1n"
+	}
+
+	for _, unit := range grpcTimeoutUnits {
+		value := timeout / unit.duration
+		if timeout%unit.duration != 0 {
+			value++
+		}
+		if value < 100000000 {
+			return strconv.FormatInt(int64(value), 10) + unit.unit
+		}
+	}
+
+	return "99999999H"
+}
@@ -0,0 +1,43 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrpcTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		expect  string
+	}{
+		{
+			name:    "Expired",
+			timeout: -time.Second,
+			expect:  "1n",
+		},
+		{
+			name:    "Nanoseconds",
+			timeout: 50 * time.Millisecond,
+			expect:  "50000000n",
+		},
+		{
+			name:    "Round up",
+			timeout: 30*time.Second + time.Nanosecond,
+			expect:  "30000001u",
+		},
+		{
+			name:    "Seconds",
+			timeout: 30 * time.Hour,
+			expect:  "108000S",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, grpcTimeout(test.timeout))
+		})
+	}
+}
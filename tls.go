@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/goravel/framework/contracts/config"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsOptions is the gateway.tls configuration, it's shared by the Gateway listener and the controller client.
+type tlsOptions struct {
+	certFile       string
+	keyFile        string
+	caFile         string
+	clientCAFile   string
+	minVersionName string
+}
+
+func newTLSOptions(config config.Config) tlsOptions {
+	certFile := config.GetString("gateway.tls.cert_file")
+	if certFile == "" {
+		return tlsOptions{}
+	}
+
+	return tlsOptions{
+		certFile:       certFile,
+		keyFile:        config.GetString("gateway.tls.key_file"),
+		caFile:         config.GetString("gateway.tls.ca_file"),
+		clientCAFile:   config.GetString("gateway.tls.client_ca_file"),
+		minVersionName: config.GetString("gateway.tls.min_version"),
+	}
+}
+
+func (r tlsOptions) enabled() bool {
+	return r.certFile != "" && r.keyFile != ""
+}
+
+// serverConfig returns the TLS config of the Gateway listener, client certificates are required if clientCAFile is set.
+func (r tlsOptions) serverConfig() (*tls.Config, error) {
+	minVersion, err := r.minVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: minVersion,
+	}
+
+	if r.clientCAFile != "" {
+		pool, err := loadCertPool(r.clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// clientConfig returns the TLS config that the controller uses to send requests to the Gateway, the certificate is
+// presented as the client certificate for mTLS and caFile is used to verify the Gateway.
+func (r tlsOptions) clientConfig() (*tls.Config, error) {
+	minVersion, err := r.minVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load gateway.tls certificate failed: %v", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   minVersion,
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	if r.caFile != "" {
+		pool, err := loadCertPool(r.caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+func (r tlsOptions) minVersion() (uint16, error) {
+	if r.minVersionName == "" {
+		return tls.VersionTLS12, nil
+	}
+
+	version, exist := tlsVersions[r.minVersionName]
+	if !exist {
+		return 0, fmt.Errorf("gateway.tls.min_version %s is invalid, it should be one of 1.0, 1.1, 1.2 and 1.3", r.minVersionName)
+	}
+
+	return version, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read CA file %s failed: %v", file, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA file %s doesn't contain any certificate", file)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,166 @@
+package gateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	configmocks "github.com/goravel/framework/mocks/config"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithTLS(t *testing.T) {
+	certFile, keyFile, caFile := generateCertificates(t)
+
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	mockRunConfig(mockConfig)
+	mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
+	mockConfig.On("GetString", "gateway.port").Return("4005")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return(certFile)
+	mockConfig.On("GetString", "gateway.tls.key_file").Return(keyFile)
+	mockConfig.On("GetString", "gateway.tls.ca_file").Return(caFile)
+	mockConfig.On("GetString", "gateway.tls.client_ca_file").Return(caFile)
+	mockConfig.On("GetString", "gateway.tls.min_version").Return("1.3")
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{})
+
+	go func() {
+		_ = gateway.Run()
+	}()
+	time.Sleep(1 * time.Second)
+	defer func() {
+		assert.Nil(t, gateway.Shutdown(context.Background()))
+	}()
+
+	gatewayConfig, err := parseConfig(mockConfig, nil)
+	require.Nil(t, err)
+	instance.Store(nil)
+	remoteClientOnce = sync.Once{}
+	defer func() {
+		remoteClientOnce = sync.Once{}
+	}()
+
+	client, url, err := newTransport(gatewayConfig, "/users")
+	require.Nil(t, err)
+	assert.Equal(t, "https://127.0.0.1:4005/users", url)
+
+	resp, err := client.Get(url)
+	require.Nil(t, err)
+	assert.Nil(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The Gateway requires a client certificate.
+	pool, err := loadCertPool(caFile)
+	require.Nil(t, err)
+	withoutCertificate := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err = withoutCertificate.Get(url)
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	assert.NotNil(t, err)
+}
+
+func TestTLSOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    tlsOptions
+		expectErr  string
+		minVersion uint16
+	}{
+		{
+			name:       "Happy path - default min version",
+			options:    tlsOptions{},
+			minVersion: tls.VersionTLS12,
+		},
+		{
+			name:       "Happy path - min version",
+			options:    tlsOptions{minVersionName: "1.3"},
+			minVersion: tls.VersionTLS13,
+		},
+		{
+			name:      "error, invalid min version",
+			options:   tlsOptions{minVersionName: "2.0"},
+			expectErr: "gateway.tls.min_version 2.0 is invalid, it should be one of 1.0, 1.1, 1.2 and 1.3",
+		},
+		{
+			name:      "error, client CA file doesn't exist",
+			options:   tlsOptions{clientCAFile: "not-exist.pem"},
+			expectErr: "read CA file not-exist.pem failed: open not-exist.pem: no such file or directory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tlsConfig, err := test.options.serverConfig()
+			if test.expectErr != "" {
+				assert.EqualError(t, err, test.expectErr)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.minVersion, tlsConfig.MinVersion)
+		})
+	}
+}
+
+// generateCertificates generates a CA and a certificate signed by it for 127.0.0.1, the certificate can be used by
+// both servers and clients.
+func generateCertificates(t *testing.T) (certFile, keyFile, caFile string) {
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "goravel"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.Nil(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "gateway"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+	require.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	certFile = filepath.Join(dir, "gateway.pem")
+	keyFile = filepath.Join(dir, "gateway.key")
+	caFile = filepath.Join(dir, "ca.pem")
+	require.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	require.Nil(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDer}), 0600))
+
+	return certFile, keyFile, caFile
+}
@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/metadata"
+)
+
+const tracerName = "github.com/goravel/gateway"
+
+// tracing creates the spans of the controller and the ServeMux, and propagates the W3C traceparent and baggage from
+// the client to the gRPC backend. The trace context is still propagated if gateway.tracing.exporter isn't set, but
+// no span is recorded.
+type tracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	// provider is nil if gateway.tracing.exporter isn't set.
+	provider *sdktrace.TracerProvider
+}
+
+func newTracing(exporter sdktrace.SpanExporter) *tracing {
+	tracing := &tracing{
+		tracer:     noop.NewTracerProvider().Tracer(tracerName),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+
+	if exporter != nil {
+		tracing.provider = sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+		tracing.tracer = tracing.provider.Tracer(tracerName)
+	}
+
+	return tracing
+}
+
+// shutdown exports the remaining spans and stops the provider.
+func (r *tracing) shutdown(ctx context.Context) error {
+	if r.provider == nil {
+		return nil
+	}
+
+	return r.provider.Shutdown(ctx)
+}
+
+// startProxy extracts the trace context sent by the client and starts the span of the hop from the controller to the
+// Gateway.
+func (r *tracing) startProxy(ctx context.Context, header http.Header, method, path string) (context.Context, trace.Span) {
+	ctx = r.propagator.Extract(ctx, propagation.HeaderCarrier(header))
+
+	return r.tracer.Start(ctx, "gateway.proxy",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.path", path),
+		),
+	)
+}
+
+// inject writes the trace context to the headers of the request sent to the Gateway.
+func (r *tracing) inject(ctx context.Context, header http.Header) {
+	r.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// register applies the middleware that starts the span of the ServeMux handler, and the annotator that injects the
+// trace context into the outgoing gRPC metadata.
+func (r *tracing) register(mux *runtime.ServeMux) {
+	runtime.WithMiddlewares(r.middleware)(mux)
+	runtime.WithMetadata(r.annotate)(mux)
+}
+
+func (r *tracing) middleware(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		ctx := r.propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := r.tracer.Start(ctx, "gateway.handle",
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.request.method", req.Method)),
+		)
+		defer span.End()
+
+		recorder := newResponseRecorder(w)
+		next(recorder, req.WithContext(ctx), params)
+
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(otelcodes.Error, http.StatusText(recorder.status))
+		}
+	}
+}
+
+// annotate names the span of the ServeMux handler with the gRPC method, and returns the trace context as the
+// metadata of the gRPC request.
+func (r *tracing) annotate(ctx context.Context, _ *http.Request) metadata.MD {
+	span := trace.SpanFromContext(ctx)
+	if rpcMethod, ok := runtime.RPCMethod(ctx); ok {
+		service, method, _ := strings.Cut(strings.TrimPrefix(rpcMethod, "/"), "/")
+		span.SetName(strings.TrimPrefix(rpcMethod, "/"))
+		span.SetAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		)
+	}
+	if route, ok := runtime.HTTPPathPattern(ctx); ok {
+		span.SetAttributes(attribute.String("http.route", route))
+	}
+
+	md := metadata.MD{}
+	r.propagator.Inject(ctx, metadataCarrier(md))
+
+	return md
+}
+
+// metadataCarrier adapts metadata.MD to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (r metadataCarrier) Get(key string) string {
+	values := metadata.MD(r).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (r metadataCarrier) Set(key, value string) {
+	metadata.MD(r).Set(key, value)
+}
+
+func (r metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(r))
+	for key := range r {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
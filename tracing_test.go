@@ -0,0 +1,119 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	contractshttp "github.com/goravel/framework/contracts/http"
+	configmocks "github.com/goravel/framework/mocks/config"
+	grpcmocks "github.com/goravel/framework/mocks/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/goravel/gateway/proto/example"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+)
+
+func TestTracing(t *testing.T) {
+	var incoming metadata.MD
+	listener, err := net.Listen("tcp", "127.0.0.1:4010")
+	require.Nil(t, err)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		incoming, _ = metadata.FromIncomingContext(ctx)
+		return handler(ctx, req)
+	}))
+	example.RegisterUserServiceServer(server, NewUserController())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	exporter := tracetest.NewInMemoryExporter()
+	mockConfig := new(configmocks.Config)
+	mockGrpc := new(grpcmocks.Grpc)
+	gateway := NewGateway(mockConfig, mockGrpc)
+
+	mockConfig.On("GetString", "gateway.host").Return("")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "gateway.fallback").Return(func(ctx contractshttp.Context, err error) contractshttp.Response {
+		return nil
+	})
+	mockConfig.On("Get", "gateway.headers").Return(nil)
+	mockConfig.On("Get", "gateway.status_codes").Return(nil)
+	mockConfig.On("GetInt", "gateway.timeout", 30).Return(30)
+	mockConfig.On("GetInt", "gateway.retry.max_attempts", 1).Return(1)
+	mockConfig.On("GetString", "gateway.connection.startup").Return("")
+	mockConfig.On("GetString", "gateway.health.healthz").Return("")
+	mockConfig.On("GetString", "gateway.health.readyz").Return("")
+	mockConfig.On("GetString", "gateway.metrics.path").Return("")
+	mockConfig.On("GetBool", "gateway.circuit_breaker.enabled").Return(false)
+	mockConfig.On("Get", "gateway.tracing.exporter").Return(exporter)
+	mockConfig.On("GetBool", "gateway.access_log.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.rate_limit.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.cache.enabled").Return(false)
+	mockConfig.On("GetBool", "gateway.coalesce.enabled").Return(false)
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"example": map[string]any{
+			"handlers": []Handler{example.RegisterUserServiceHandler},
+		},
+	})
+	mockGrpc.On("Client", context.Background(), "example").Return(newTestConnection(t, "127.0.0.1:4010"), nil)
+
+	require.Nil(t, gateway.Run())
+
+	// The controller extracts the trace context of the client and injects its span to the request sent to the Gateway.
+	tracing := currentComponents().tracing
+	header := http.Header{}
+	header.Set("traceparent", traceparent)
+	header.Set("baggage", "tenant=goravel")
+	ctx, span := tracing.startProxy(context.Background(), header, http.MethodGet, "/users/1")
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil).WithContext(ctx)
+	tracing.inject(ctx, req.Header)
+	span.End()
+
+	recorder := httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Shutdown resets the in-memory exporter, so the spans are flushed before it.
+	require.Nil(t, tracing.provider.ForceFlush(context.Background()))
+	spans := exporter.GetSpans()
+	require.Nil(t, gateway.Shutdown(context.Background()))
+
+	// The trace context is injected into the gRPC metadata.
+	require.Len(t, incoming.Get("traceparent"), 1)
+	assert.Contains(t, incoming.Get("traceparent")[0], traceID)
+	assert.Equal(t, []string{"tenant=goravel"}, incoming.Get("baggage"))
+
+	require.Len(t, spans, 2)
+
+	proxy, handle := spans[0], spans[1]
+	assert.Equal(t, "gateway.proxy", proxy.Name)
+	assert.Equal(t, trace.SpanKindClient, proxy.SpanKind)
+	assert.Equal(t, traceID, proxy.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", proxy.Parent.SpanID().String())
+
+	assert.Equal(t, "example.UserService/GetUser", handle.Name)
+	assert.Equal(t, trace.SpanKindServer, handle.SpanKind)
+	assert.Equal(t, traceID, handle.SpanContext.TraceID().String())
+	assert.Equal(t, proxy.SpanContext.SpanID(), handle.Parent.SpanID())
+	assert.Contains(t, handle.Attributes, attribute.String("rpc.service", "example.UserService"))
+	assert.Contains(t, handle.Attributes, attribute.String("http.route", "/users/{id}"))
+	assert.Contains(t, handle.Attributes, attribute.Int("http.response.status_code", http.StatusOK))
+
+	mockConfig.AssertExpectations(t)
+	mockGrpc.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+var (
+	remoteClientOnce sync.Once
+	remoteClient     *http.Client
+	remoteScheme     string
+	remoteClientErr  error
+)
+
+// inProcessTransport dispatches requests to the ServeMux of the Gateway running in the current process, it avoids the
+// TCP round-trip to gateway.host and gateway.port. The response is returned once its header is written, and the body
+// is piped from the handler, so the messages of a stream can be read as they are written.
+type inProcessTransport struct {
+	handler http.Handler
+}
+
+func (r *inProcessTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	writer := newPipeResponseWriter(req)
+	go func() {
+		defer writer.close()
+		r.handler.ServeHTTP(writer, req)
+	}()
+
+	<-writer.ready
+
+	return writer.resp, nil
+}
+
+// pipeResponseWriter is the http.ResponseWriter of inProcessTransport, the body written by the handler is read from
+// the body of resp.
+type pipeResponseWriter struct {
+	header http.Header
+	req    *http.Request
+	resp   *http.Response
+	body   *io.PipeWriter
+	ready  chan struct{}
+}
+
+func newPipeResponseWriter(req *http.Request) *pipeResponseWriter {
+	return &pipeResponseWriter{
+		header: make(http.Header),
+		req:    req,
+		ready:  make(chan struct{}),
+	}
+}
+
+func (r *pipeResponseWriter) Header() http.Header {
+	return r.header
+}
+
+// WriteHeader builds resp like net/http does, the changes of the header after it are ignored.
+func (r *pipeResponseWriter) WriteHeader(status int) {
+	if r.resp != nil {
+		return
+	}
+
+	body, writer := io.Pipe()
+	header := r.header.Clone()
+	header.Del("Transfer-Encoding")
+
+	r.body = writer
+	r.resp = &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: -1,
+		Request:       r.req,
+	}
+	close(r.ready)
+}
+
+// Write blocks until the body is read, it fails once the body is closed.
+func (r *pipeResponseWriter) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+
+	return r.body.Write(data)
+}
+
+// Flush does nothing, the body isn't buffered.
+func (r *pipeResponseWriter) Flush() {}
+
+// close ends the body once the handler returns, and reports the panic of the handler as an error of the body.
+func (r *pipeResponseWriter) close() {
+	p := recover()
+	r.WriteHeader(http.StatusOK)
+	if p != nil {
+		_ = r.body.CloseWithError(fmt.Errorf("gateway handler panicked: %v", p))
+		return
+	}
+
+	_ = r.body.Close()
+}
+
+// newTransport returns the client and the URL that the request of path should be sent to.
+func newTransport(gatewayConfig *gatewayConfig, path string) (*http.Client, string, error) {
+	if gateway := instance.Load(); gateway != nil {
+		return &http.Client{Transport: &inProcessTransport{handler: gateway}}, path, nil
+	}
+
+	remoteClientOnce.Do(func() {
+		remoteClient, remoteScheme, remoteClientErr = newRemoteClient(gatewayConfig.tls)
+	})
+	if remoteClientErr != nil {
+		return nil, "", remoteClientErr
+	}
+
+	return remoteClient, fmt.Sprintf("%s://%s:%s%s", remoteScheme, gatewayConfig.host, gatewayConfig.port, path), nil
+}
+
+// newRemoteClient returns the client that sends requests to gateway.host and gateway.port, via HTTPS if gateway.tls is
+// set.
+func newRemoteClient(tlsOptions tlsOptions) (*http.Client, string, error) {
+	if !tlsOptions.enabled() {
+		return http.DefaultClient, "http", nil
+	}
+
+	tlsConfig, err := tlsOptions.clientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, "https", nil
+}
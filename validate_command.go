@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"errors"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+)
+
+// ValidateCommand reports the problems of the gateway and grpc.servers configuration, run it in CI to catch
+// misconfiguration before deploy.
+type ValidateCommand struct {
+	gateway *Gateway
+}
+
+func NewValidateCommand(gateway *Gateway) *ValidateCommand {
+	return &ValidateCommand{gateway: gateway}
+}
+
+func (r *ValidateCommand) Signature() string {
+	return "gateway:validate"
+}
+
+func (r *ValidateCommand) Description() string {
+	return "Validate the gateway and gRPC configuration"
+}
+
+func (r *ValidateCommand) Extend() command.Extend {
+	return command.Extend{
+		Category: "gateway",
+	}
+}
+
+func (r *ValidateCommand) Handle(ctx console.Context) error {
+	err := r.gateway.Validate()
+	if err == nil {
+		ctx.Info("The gateway configuration is valid")
+		return nil
+	}
+
+	if joinErr, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, configErr := range joinErr.Unwrap() {
+			ctx.Error(configErr.Error())
+		}
+	} else {
+		ctx.Error(err.Error())
+	}
+
+	return errors.New("the gateway configuration is invalid")
+}
@@ -0,0 +1,30 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+
+	configmocks "github.com/goravel/framework/mocks/config"
+	consolemocks "github.com/goravel/framework/mocks/console"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCommand(t *testing.T) {
+	mockConfig := new(configmocks.Config)
+	mockRunConfig(mockConfig)
+	mockConfig.On("GetString", "gateway.host").Return("127.0.0.1")
+	mockConfig.On("GetString", "gateway.port").Return("")
+	mockConfig.On("GetString", "gateway.tls.cert_file").Return("")
+	mockConfig.On("Get", "grpc.servers").Return(map[string]any{
+		"user": map[string]any{},
+	})
+
+	mockContext := consolemocks.NewContext(t)
+	mockContext.EXPECT().Error("gateway.port: please initialize GATEWAY_PORT, it's required if gateway.host is set").Once()
+	mockContext.EXPECT().Error("grpc.servers.user.handlers: is required").Once()
+
+	command := NewValidateCommand(NewGateway(mockConfig, nil))
+	assert.Equal(t, errors.New("the gateway configuration is invalid"), command.Handle(mockContext))
+
+	mockConfig.AssertExpectations(t)
+}